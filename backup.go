@@ -0,0 +1,478 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// backupFormat is the version of the archive layout written by export
+const backupFormat = 1
+
+// maxBackupEntrySize caps each file unpacked from an archive, which may come
+// from anywhere
+const maxBackupEntrySize = 256 << 20
+
+const (
+	backupManifestFile = "manifest.json"
+	backupScopeFile    = "scope.json"
+	backupMetadataFile = "metadata.json"
+)
+
+// BackupManifest describes a company backup archive. Every other file in the
+// archive is listed with its item count and SHA-256 checksum.
+type BackupManifest struct {
+	Format    int          `json:"format"`
+	Company   string       `json:"company"`
+	CreatedAt time.Time    `json:"created_at"`
+	Files     []BackupFile `json:"files"`
+}
+
+// BackupFile is one data file of a backup archive
+type BackupFile struct {
+	Name   string `json:"name"`
+	Items  int    `json:"items"`
+	SHA256 string `json:"sha256"`
+}
+
+// Backup is the data of one company as stored in an archive
+type Backup struct {
+	Manifest BackupManifest
+	Assets   map[string][]string
+	Scope    ScopeFile
+	Metadata map[string]string
+}
+
+// createCompanyExportCommand builds "company export", which writes a
+// company's data to a tar.gz archive
+func createCompanyExportCommand() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export -o <file.tar.gz>",
+		Short: "📦 Back up a company to an archive",
+		Long: `Write the domains, IPs, ASNs, scope and metadata of a company to a tar.gz
+archive, one JSON file each, with a manifest holding their checksums. Restore
+it with company import.`,
+		Example: `  bbrf company export -o acme.tar.gz -c acme
+
+  # Dated backup
+  bbrf company export acme -o acme-$(date +%F).tar.gz`,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+			if output == "" {
+				output = company + ".tar.gz"
+			}
+
+			backup := fetchBackup(company)
+			archive, err := writeBackup(backup)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to build archive: " + err.Error()))
+				os.Exit(1)
+			}
+			if err := os.WriteFile(output, archive, 0600); err != nil {
+				fmt.Println(errorC("❌ Failed to write archive: " + err.Error()))
+				os.Exit(1)
+			}
+
+			printBackup(backup)
+			fmt.Println(success(fmt.Sprintf("✅ Exported %s to %s", company, output)))
+		},
+	}
+	exportCmd.Flags().StringP("output", "o", "", "Archive to write (default <company>.tar.gz)")
+
+	return exportCmd
+}
+
+// createCompanyImportCommand builds "company import", which restores an
+// export archive through the add endpoints
+func createCompanyImportCommand() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import <file.tar.gz>",
+		Short: "📥 Restore a company from an archive",
+		Long: `Restore a company from an archive written by company export. The checksums
+in the manifest are verified before anything is sent.
+
+The company is created if it doesn't exist. Restoring into an existing company
+only adds: scope patterns it already has on the other list and metadata keys it
+already has with another value are left alone and reported, and nothing it has
+is removed. Assets are checked against the merged scope like company merge
+does, unless --allow-out-of-scope is given; a new company gets them all.`,
+		Example: `  # Restore under the exported name
+  bbrf company import acme.tar.gz
+
+  # Restore as a new company, 1000 items per request
+  bbrf company import acme.tar.gz --as acme-restored --batch-size 1000`,
+		Annotations: map[string]string{companyAnnotation: companyOptional},
+		Args:        cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			as, _ := cmd.Flags().GetString("as")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			autoApprove, _ := cmd.Flags().GetBool("yes")
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+			if batchSize < 1 {
+				batchSize = 1
+			}
+
+			archive, err := os.ReadFile(args[0])
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to read archive: " + err.Error()))
+				os.Exit(1)
+			}
+			backup, err := readBackup(archive)
+			if err != nil {
+				fmt.Println(errorC("❌ Invalid archive: " + err.Error()))
+				os.Exit(1)
+			}
+
+			target := backup.Manifest.Company
+			if as != "" {
+				target = as
+			}
+			if target == "" {
+				fmt.Println(errorC("❌ The archive names no company, use --as"))
+				os.Exit(1)
+			}
+
+			respData, err := apiRequest("GET", companyListEndpoint, nil)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to list companies: " + err.Error()))
+				os.Exit(1)
+			}
+			var companies []string
+			if err := json.Unmarshal(respData, &companies); err != nil {
+				fmt.Println(errorC("❌ Failed to parse company list: " + err.Error()))
+				os.Exit(1)
+			}
+			exists := slices.Contains(companies, target)
+
+			current := &ScopeFile{Company: target}
+			currentMetadata := map[string]string{}
+			if exists {
+				current = mustFetchScopeFile(target)
+				currentMetadata = mustFetchMetadata(target)
+			}
+			backup.Scope.Company = backup.Manifest.Company
+			merged, conflicts := mergeScopeFiles(current, &backup.Scope)
+			plan := planScope(current, merged)
+			metadata, metadataConflicts := planMetadata(currentMetadata, backup.Metadata)
+			conflicts = append(conflicts, metadataConflicts...)
+
+			// A new company takes the archive as it is; an existing one only
+			// gets the assets it lacks that pass the merged scope
+			var sm *ScopeManager
+			dst := ""
+			if exists {
+				sm = NewScopeManager(target)
+				sm.setRules(merged.InScope, merged.OutScope)
+				dst = target
+			}
+			copies := planAssetAdds(backup.Assets, dst, sm)
+
+			fmt.Println(title(fmt.Sprintf("Import %s (exported %s) → %s", backup.Manifest.Company,
+				backup.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"), target)))
+			if exists {
+				fmt.Println(warning(fmt.Sprintf("⚠️ %s already exists, the archive is added to it", target)))
+			}
+			printBackup(backup)
+			printScopePlan(plan)
+			if len(conflicts) > 0 {
+				fmt.Println(warning(fmt.Sprintf("⚠️ %d conflicts, keeping what %s has:", len(conflicts), target)))
+				for _, conflict := range conflicts {
+					fmt.Printf("  %s %s\n", warning("!"), conflict)
+				}
+			}
+			printAssetCopies(copies)
+
+			if dryRun {
+				fmt.Println(info("ℹ️  Dry run, nothing imported."))
+				return
+			}
+			if !autoApprove && !confirm(fmt.Sprintf("Import into %s?", target)) {
+				fmt.Println(warning("⚠️ Import cancelled"))
+				return
+			}
+
+			if !exists {
+				if _, err := apiRequest("POST", "/api/company", map[string]string{"company": target}); err != nil {
+					fmt.Println(errorC("❌ Failed to create company: " + err.Error()))
+					os.Exit(1)
+				}
+			}
+			if !plan.Empty() {
+				if err := applyScopePlan(target, plan, "company import"); err != nil {
+					fmt.Println(errorC("❌ Failed to restore scope: " + err.Error()))
+					os.Exit(1)
+				}
+			}
+
+			if remaining, err := copyAssets(target, copies, batchSize); err != nil {
+				fmt.Println(errorC("❌ Failed to restore assets: " + err.Error()))
+				exitUnfinished(fmt.Sprintf("The scope of %s was restored, but not all assets or its metadata", target), remaining,
+					"Importing again adds what is missing")
+			}
+
+			for _, key := range sortedKeys(metadata) {
+				body := map[string]string{"company": target, "key": key, "value": metadata[key]}
+				if _, err := apiRequest("POST", companyMetaEndpoint, body); err != nil {
+					fmt.Println(errorC("❌ Failed to restore metadata: " + err.Error()))
+					os.Exit(1)
+				}
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Imported %s into %s", args[0], target)))
+		},
+	}
+	importCmd.Flags().String("as", "", "Restore under this company name instead of the exported one")
+	importCmd.Flags().Int("batch-size", 500, "Items per add request")
+	addCompanyOpFlags(importCmd)
+
+	return importCmd
+}
+
+// fetchBackup reads everything export stores about a company, exiting on
+// failure so that no partial archive is written
+func fetchBackup(company string) *Backup {
+	backup := &Backup{
+		Manifest: BackupManifest{Format: backupFormat, Company: company, CreatedAt: time.Now().UTC()},
+		Assets:   make(map[string][]string),
+	}
+
+	for _, resource := range companyAssetResources {
+		items, err := fetchResourceList(resource.listEndpoint, company)
+		if err != nil {
+			fmt.Println(errorC(fmt.Sprintf("❌ Failed to list %ss: %s", resource.name, err.Error())))
+			os.Exit(1)
+		}
+		if items == nil {
+			items = []string{}
+		}
+		backup.Assets[resource.dataKey] = items
+	}
+
+	backup.Scope = *mustFetchScopeFile(company)
+
+	backup.Metadata = mustFetchMetadata(company)
+
+	return backup
+}
+
+// mustFetchMetadata reads the metadata of a company, exiting if it can't
+func mustFetchMetadata(company string) map[string]string {
+	respData, err := apiRequest("GET", companyInfoEndpoint+"?company="+url.QueryEscape(company), nil)
+	if err != nil {
+		fmt.Println(errorC("❌ Failed to get company metadata: " + err.Error()))
+		os.Exit(1)
+	}
+	var companyInfo CompanyInfo
+	if err := json.Unmarshal(respData, &companyInfo); err != nil {
+		fmt.Println(errorC("❌ Failed to parse company metadata: " + err.Error()))
+		os.Exit(1)
+	}
+	if companyInfo.Metadata == nil {
+		return map[string]string{}
+	}
+	return companyInfo.Metadata
+}
+
+// planMetadata returns the archived metadata keys to set on a company that
+// has current, and a conflict for each key it has with another value, which
+// is kept
+func planMetadata(current, archived map[string]string) (map[string]string, []string) {
+	set := make(map[string]string)
+	var conflicts []string
+	for _, key := range sortedKeys(archived) {
+		value, ok := current[key]
+		switch {
+		case !ok:
+			set[key] = archived[key]
+		case value != archived[key]:
+			conflicts = append(conflicts, fmt.Sprintf("%s is %s in the archive but %s on the server", key, archived[key], value))
+		}
+	}
+	return set, conflicts
+}
+
+// writeBackup builds the tar.gz archive of a backup, filling in the manifest.
+// Each asset list is <dataKey>.json.
+func writeBackup(backup *Backup) ([]byte, error) {
+	type dataFile struct {
+		name  string
+		items int
+		value interface{}
+	}
+	var files []dataFile
+	for _, resource := range companyAssetResources {
+		items := backup.Assets[resource.dataKey]
+		files = append(files, dataFile{resource.dataKey + ".json", len(items), items})
+	}
+	files = append(files,
+		dataFile{backupScopeFile, len(backup.Scope.InScope) + len(backup.Scope.OutScope), backup.Scope},
+		dataFile{backupMetadataFile, len(backup.Metadata), backup.Metadata},
+	)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	writeFile := func(name string, content []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: backup.Manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+
+	backup.Manifest.Files = nil
+	var contents [][]byte
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		backup.Manifest.Files = append(backup.Manifest.Files, BackupFile{
+			Name:   file.name,
+			Items:  file.items,
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+		contents = append(contents, content)
+	}
+
+	// The manifest goes first so import can read it before the data
+	manifest, err := json.MarshalIndent(backup.Manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(backupManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		if err := writeFile(file.name, contents[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readBackup unpacks an archive written by writeBackup, checking every file
+// listed in the manifest against its checksum
+func readBackup(archive []byte) (*Backup, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if _, ok := contents[name]; ok {
+			return nil, fmt.Errorf("%s appears twice", name)
+		}
+		if header.Size > maxBackupEntrySize {
+			return nil, fmt.Errorf("%s is larger than %d MB", name, maxBackupEntrySize>>20)
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxBackupEntrySize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > maxBackupEntrySize {
+			return nil, fmt.Errorf("%s is larger than %d MB", name, maxBackupEntrySize>>20)
+		}
+		contents[name] = content
+	}
+
+	backup := &Backup{Assets: make(map[string][]string)}
+	content, ok := contents[backupManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("no %s", backupManifestFile)
+	}
+	if err := json.Unmarshal(content, &backup.Manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", backupManifestFile, err)
+	}
+	if backup.Manifest.Format != backupFormat {
+		return nil, fmt.Errorf("unsupported format %d, this version reads format %d", backup.Manifest.Format, backupFormat)
+	}
+
+	listed := make(map[string]bool)
+	for _, file := range backup.Manifest.Files {
+		content, ok := contents[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s is listed in the manifest but missing", file.Name)
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != file.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s", file.Name)
+		}
+		listed[file.Name] = true
+	}
+
+	for _, resource := range companyAssetResources {
+		name := resource.dataKey + ".json"
+		if !listed[name] {
+			return nil, fmt.Errorf("no %s", name)
+		}
+		var items []string
+		if err := json.Unmarshal(contents[name], &items); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		backup.Assets[resource.dataKey] = items
+	}
+	if !listed[backupScopeFile] {
+		return nil, fmt.Errorf("no %s", backupScopeFile)
+	}
+	if err := json.Unmarshal(contents[backupScopeFile], &backup.Scope); err != nil {
+		return nil, fmt.Errorf("%s: %w", backupScopeFile, err)
+	}
+	backup.Scope.InScope = cleanScopePatterns(backup.Scope.InScope)
+	backup.Scope.OutScope = cleanScopePatterns(backup.Scope.OutScope)
+	if listed[backupMetadataFile] {
+		if err := json.Unmarshal(contents[backupMetadataFile], &backup.Metadata); err != nil {
+			return nil, fmt.Errorf("%s: %w", backupMetadataFile, err)
+		}
+	}
+
+	return backup, nil
+}
+
+// printBackup lists what a backup holds
+func printBackup(backup *Backup) {
+	for _, resource := range companyAssetResources {
+		fmt.Printf("  %s %d %ss\n", info("•"), len(backup.Assets[resource.dataKey]), resource.name)
+	}
+	fmt.Printf("  %s %d in-scope and %d out-of-scope patterns\n", info("•"), len(backup.Scope.InScope), len(backup.Scope.OutScope))
+	fmt.Printf("  %s %d metadata keys\n", info("•"), len(backup.Metadata))
+}
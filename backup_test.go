@@ -0,0 +1,217 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testBackup() *Backup {
+	return &Backup{
+		Manifest: BackupManifest{
+			Format:    backupFormat,
+			Company:   "acme",
+			CreatedAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		},
+		Assets: map[string][]string{
+			"domains": {"a.example.com:10.0.0.1", "b.example.com"},
+			"ips":     {"10.0.0.1"},
+			"asns":    {},
+		},
+		Scope: ScopeFile{
+			Company:  "acme",
+			InScope:  []string{"*.example.com", `re:api\d+\.example\.org`},
+			OutScope: []string{"*.corp.example.com"},
+		},
+		Metadata: map[string]string{"platform": "h1", "status": "active"},
+	}
+}
+
+// repackBackup rewrites the files of an archive through edit; a nil result
+// drops the file
+func repackBackup(t *testing.T, archive []byte, edit func(name string, content []byte) []byte) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content = edit(header.Name, content); content == nil {
+			continue
+		}
+		header.Size = int64(len(content))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	want := testBackup()
+	archive, err := writeBackup(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want.Manifest.Files) != 5 {
+		t.Fatalf("manifest lists %d files, want 5", len(want.Manifest.Files))
+	}
+	for _, file := range want.Manifest.Files {
+		if file.Name == "domains.json" && file.Items != 2 {
+			t.Errorf("domains.json counted %d items, want 2", file.Items)
+		}
+	}
+
+	got, err := readBackup(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Manifest, want.Manifest) {
+		t.Errorf("manifest %+v, want %+v", got.Manifest, want.Manifest)
+	}
+	if !reflect.DeepEqual(got.Assets, want.Assets) {
+		t.Errorf("assets %v, want %v", got.Assets, want.Assets)
+	}
+	if !reflect.DeepEqual(got.Scope, want.Scope) {
+		t.Errorf("scope %+v, want %+v", got.Scope, want.Scope)
+	}
+	if !reflect.DeepEqual(got.Metadata, want.Metadata) {
+		t.Errorf("metadata %v, want %v", got.Metadata, want.Metadata)
+	}
+}
+
+func TestReadBackupRejects(t *testing.T) {
+	archive, err := writeBackup(testBackup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		archive []byte
+		err     string
+	}{
+		{
+			name: "tampered data file",
+			archive: repackBackup(t, archive, func(name string, content []byte) []byte {
+				if name == "ips.json" {
+					return []byte(`["10.0.0.1", "8.8.8.8"]`)
+				}
+				return content
+			}),
+			err: "checksum mismatch for ips.json",
+		},
+		{
+			name: "tampered scope",
+			archive: repackBackup(t, archive, func(name string, content []byte) []byte {
+				if name == backupScopeFile {
+					return bytes.Replace(content, []byte("*.example.com"), []byte("*.com"), 1)
+				}
+				return content
+			}),
+			err: "checksum mismatch for scope.json",
+		},
+		{
+			name: "missing data file",
+			archive: repackBackup(t, archive, func(name string, content []byte) []byte {
+				if name == "domains.json" {
+					return nil
+				}
+				return content
+			}),
+			err: "domains.json is listed in the manifest but missing",
+		},
+		{
+			name: "missing manifest",
+			archive: repackBackup(t, archive, func(name string, content []byte) []byte {
+				if name == backupManifestFile {
+					return nil
+				}
+				return content
+			}),
+			err: "no manifest.json",
+		},
+		{
+			name: "newer format",
+			archive: repackBackup(t, archive, func(name string, content []byte) []byte {
+				if name == backupManifestFile {
+					return bytes.Replace(content, []byte(`"format": 1`), []byte(`"format": 2`), 1)
+				}
+				return content
+			}),
+			err: "unsupported format 2",
+		},
+		{
+			name:    "not an archive",
+			archive: []byte("not a tar.gz"),
+			err:     "gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := readBackup(tt.archive)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want one containing %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestReadBackupRejectsOversizedEntry(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	// Only the header is written, its size alone must get the entry rejected
+	if err := tw.WriteHeader(&tar.Header{Name: "domains.json", Mode: 0600, Size: maxBackupEntrySize + 1}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Flush()
+	gz.Close()
+
+	_, err := readBackup(buf.Bytes())
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("got error %v, want an oversized entry error", err)
+	}
+}
+
+func TestPlanMetadata(t *testing.T) {
+	set, conflicts := planMetadata(
+		map[string]string{"platform": "h1", "status": "active"},
+		map[string]string{"platform": "bugcrowd", "status": "active", "bounty": "paid"},
+	)
+	if want := map[string]string{"bounty": "paid"}; !reflect.DeepEqual(set, want) {
+		t.Errorf("set %v, want %v", set, want)
+	}
+	if want := []string{"platform is bugcrowd in the archive but h1 on the server"}; !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("conflicts %q, want %q", conflicts, want)
+	}
+}
@@ -9,11 +9,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/fang"
 	"github.com/fatih/color"
@@ -23,12 +24,10 @@ import (
 type Config struct {
 	Token string `json:"token"`
 	API   string `json:"api"`
-}
-
-type ScopeManager struct {
-	InScope  []string
-	OutScope []string
-	company  string
+	// ScopeStrict makes scope strict mode the default, for shared team configs
+	ScopeStrict bool `json:"scope_strict,omitempty"`
+	// ScopeCacheTTL is how long cached scope rules are used, e.g. "10m"
+	ScopeCacheTTL string `json:"scope_cache_ttl,omitempty"`
 }
 
 var (
@@ -43,6 +42,10 @@ var (
 	enableScopeFilter bool
 	allowOutOfScope   bool
 	verboseScope      bool
+	scopeStrict       bool
+	scopeCacheTTLFlag time.Duration
+	asnDatasetPath    string
+	outputFormat      string
 
 	// Color functions using fatih/color
 	title     = color.New(color.FgMagenta, color.Bold).SprintFunc()
@@ -96,14 +99,21 @@ var rootCmd = &cobra.Command{
   bbrf company domain add example.com -c example --scope-filter=false
 
   # Allow out-of-scope domains
-  bbrf company domain add example.com -c example --allow-out-of-scope`,
+  bbrf company domain add example.com -c example --allow-out-of-scope
+
+  # Refuse to add anything if the scope can't be loaded
+  bbrf company domain add @domains.txt -c example --scope-strict`,
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&company, "company", "c", "", "Company name (required for most commands)")
 	rootCmd.PersistentFlags().BoolVar(&enableScopeFilter, "scope-filter", true, "Enable automatic scope filtering")
-	rootCmd.PersistentFlags().BoolVar(&allowOutOfScope, "allow-out-of-scope", false, "Allow out-of-scope domains to be added")
+	rootCmd.PersistentFlags().BoolVar(&allowOutOfScope, "allow-out-of-scope", false, "Allow out-of-scope domains, IPs and ASNs to be added")
 	rootCmd.PersistentFlags().BoolVar(&verboseScope, "verbose-scope", false, "Show detailed scope filtering info")
+	rootCmd.PersistentFlags().BoolVar(&scopeStrict, "scope-strict", false, "Abort adds when scope rules can't be loaded (default from scope_strict in config)")
+	rootCmd.PersistentFlags().DurationVar(&scopeCacheTTLFlag, "scope-cache-ttl", defaultScopeCacheTTL, "How long cached scope rules are used before asking the server (default from scope_cache_ttl in config, 0 always revalidates)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output-format", "table", "Output format of API results: "+strings.Join(outputFormats, ", "))
+	rootCmd.PersistentFlags().StringVar(&asnDatasetPath, "asn-db", "", "Prefix-to-ASN dataset used to match IPs against ASN scope (default ~/.bbrf/ip2asn.tsv)")
 
 	// Add all commands
 	rootCmd.AddCommand(
@@ -113,16 +123,60 @@ func init() {
 			Example: "  bbrf login",
 			Run:     func(cmd *cobra.Command, args []string) { doLogin() },
 		},
-		&cobra.Command{
-			Use:     "companies",
-			Short:   "🏢 List all companies",
-			Example: "  bbrf companies",
-			Run:     func(cmd *cobra.Command, args []string) { call("GET", "/api/company/list", "") },
-		},
+		createCompaniesCommand(),
 		createCompanyCommands(),
 	)
 }
 
+func createCompaniesCommand() *cobra.Command {
+	companiesCmd := &cobra.Command{
+		Use:   "companies",
+		Short: "🏢 List all companies",
+		Example: `  bbrf companies
+
+  # Active HackerOne programs
+  bbrf companies --platform h1 --active
+
+  # Private paid programs
+  bbrf companies --visibility private --bounty paid`,
+		Run: func(cmd *cobra.Command, args []string) {
+			query, err := companiesQuery(cmd)
+			if err != nil {
+				fmt.Println(errorC("❌ " + err.Error()))
+				os.Exit(1)
+			}
+			call("GET", companyListEndpoint+query, "")
+		},
+	}
+	addCompaniesFilterFlags(companiesCmd)
+	return companiesCmd
+}
+
+// companyAnnotation marks company subcommands that don't need -c: either
+// companyFromArg, which take the company as the first argument when -c is
+// missing, or companyOptional, which find it themselves
+const companyAnnotation = "bbrf_company"
+
+const (
+	companyFromArg  = "from-arg"
+	companyOptional = "optional"
+)
+
+// resolveCompany sets the company of a company subcommand, taking it from the
+// first argument where the command allows it, and reports whether the
+// command has the company it needs
+func resolveCompany(cmd *cobra.Command, args []string) bool {
+	switch cmd.Annotations[companyAnnotation] {
+	case companyOptional:
+		return true
+	case companyFromArg:
+		if company == "" && len(args) > 0 {
+			company = args[0]
+		}
+	}
+	return company != ""
+}
+
 func createCompanyCommands() *cobra.Command {
 	companyCmd := &cobra.Command{
 		Use:   "company",
@@ -148,8 +202,9 @@ func createCompanyCommands() *cobra.Command {
   # Manage scope
   bbrf company scope inscope domain.com -c acme`,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
-			if company == "" && len(args) > 0 {
-				company = args[0]
+			if !resolveCompany(cmd, args) {
+				fmt.Println(errorC("❌ Please specify the company with -c"))
+				os.Exit(1)
 			}
 		},
 	}
@@ -164,6 +219,7 @@ func createCompanyCommands() *cobra.Command {
 
   # Add a company using argument
   bbrf company add -c acme`,
+			Annotations: map[string]string{companyAnnotation: companyFromArg},
 			Run: func(cmd *cobra.Command, args []string) {
 				fmt.Println(info("📝 Adding company: " + company))
 				call("POST", "/api/company", fmt.Sprintf(`{"company":"%s"}`, company))
@@ -177,31 +233,43 @@ func createCompanyCommands() *cobra.Command {
 
 		  # Remove a company using argument
 		  bbrf company remove acme`,
+			Annotations: map[string]string{companyAnnotation: companyFromArg},
 			Run: func(cmd *cobra.Command, args []string) {
 				fmt.Println(info("🗑️ Removing company: " + company))
 				call("POST", "/api/company/remove", fmt.Sprintf(`{"company":"%s"}`, company))
 			},
 		},
-		&cobra.Command{
-			Use:   "show <query> [count]",
-			Short: "👁️  Show matching domains",
-			Example: `  # Show domains matching a pattern
+	)
+
+	showCmd := &cobra.Command{
+		Use:   "show <query> [count]",
+		Short: "👁️  Show matching domains",
+		Example: `  # Show domains matching a pattern
   bbrf company show "*.example.com" -c acme
 
   # Show domains with count
-  bbrf company show "*.example.com" count -c acme`,
-			Args: cobra.MinimumNArgs(1),
-			Run: func(cmd *cobra.Command, args []string) {
-				query := args[0]
-				countFlag := "false"
-				if len(args) > 1 && args[1] == "count" {
-					countFlag = "true"
-				}
-				fmt.Println(info(fmt.Sprintf("🔍 Searching for domains matching '%s' in %s", query, company)))
-				call("GET", fmt.Sprintf("/api/domains/show?company=%s&q=%s&count=%s", company, query, countFlag), "")
-			},
+  bbrf company show "*.example.com" count -c acme
+
+  # Show matching domains found this week
+  bbrf company show "*.example.com" --since 7d -c acme`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			query := args[0]
+			countFlag := "false"
+			if len(args) > 1 && args[1] == "count" {
+				countFlag = "true"
+			}
+			seenQuery, err := seenFilterQuery(cmd)
+			if err != nil {
+				fmt.Println(errorC("❌ " + err.Error()))
+				os.Exit(1)
+			}
+			fmt.Println(info(fmt.Sprintf("🔍 Searching for domains matching '%s' in %s", query, company)))
+			call("GET", fmt.Sprintf("/api/domains/show?company=%s&q=%s&count=%s", company, query, countFlag)+seenQuery, "")
 		},
-	)
+	}
+	addSeenFilterFlags(showCmd)
+	companyCmd.AddCommand(showCmd)
 
 	// Complex commands with subcommands
 	companyCmd.AddCommand(
@@ -210,20 +278,38 @@ func createCompanyCommands() *cobra.Command {
 			"remove": "/api/domains/remove",
 			"list":   "/api/domains",       // Added list endpoint for domains
 			"count":  "/api/domains/count", // Added count endpoint for domains
+			"tag":    "/api/domains/tag",
+			"untag":  "/api/domains/untag",
 		}),
 		createCRUDCommand("ip", "ips", map[string]string{
 			"add":    "/api/ip",
 			"remove": "/api/ip/remove",
 			"list":   "/api/ip/list",
 			"count":  "/api/ip/count", // Added count endpoint for ips
+			"tag":    "/api/ip/tag",
+			"untag":  "/api/ip/untag",
 		}),
 		createCRUDCommand("asn", "asns", map[string]string{
 			"add":    "/api/asn/add",
 			"remove": "/api/asn/remove",
 			"list":   "/api/asn/list",
 			"count":  "/api/asn/count", // Added count endpoint for asns
+			"tag":    "/api/asn/tag",
+			"untag":  "/api/asn/untag",
 		}),
+		createURLCommand(),
+		createServiceCommand(),
 		createScopeCommand(),
+		createFindingCommand(),
+		createNoteCommand(),
+		createStatsCommand(),
+		createCompanySetCommand(),
+		createCompanyInfoCommand(),
+		createCompanyRenameCommand(),
+		createCompanyCloneCommand(),
+		createCompanyMergeCommand(),
+		createCompanyExportCommand(),
+		createCompanyImportCommand(),
 	)
 
 	return companyCmd
@@ -256,16 +342,46 @@ func createCRUDCommand(name, dataKey string, endpoints map[string]string) *cobra
 		action, endpoint := action, endpoint // capture loop vars
 		actionEmoji := getEmojiForAction(action)
 
-		if action == "list" {
-			cmd.AddCommand(&cobra.Command{
-				Use:     action,
-				Short:   fmt.Sprintf("%s List %s", actionEmoji, name+"s"),
-				Example: fmt.Sprintf("  bbrf company %s list -c acme", name),
+		if action == "tag" || action == "untag" {
+			// Both are handled by the tag subcommand
+			continue
+		} else if action == "list" {
+			listCmd := &cobra.Command{
+				Use:   action,
+				Short: fmt.Sprintf("%s List %s", actionEmoji, name+"s"),
+				Example: fmt.Sprintf(`  bbrf company %s list -c acme
+
+  # Only %s tagged prod
+  bbrf company %s list --tag prod -c acme
+
+  # %s first seen in the last day, as CSV
+  bbrf company %s list --since 24h --output-format csv -c acme
+
+  # %s not seen again for a month
+  bbrf company %s list --stale 30d -c acme`, name, name+"s", name, strings.Title(name+"s"), name, strings.Title(name+"s"), name),
 				Run: func(cmd *cobra.Command, args []string) {
 					// fmt.Println(info(fmt.Sprintf("%s Listing %s for: %s", actionEmoji, name+"s", company)))
-					call("GET", endpoint+"?company="+company, "")
+					seenQuery, err := seenFilterQuery(cmd)
+					if err != nil {
+						fmt.Println(errorC("❌ " + err.Error()))
+						os.Exit(1)
+					}
+					tags, _ := cmd.Flags().GetStringSlice("tag")
+					source, _ := cmd.Flags().GetString("source")
+					query := "?company=" + company + seenQuery
+					if len(tags) > 0 {
+						query += "&tag=" + url.QueryEscape(strings.Join(tags, ","))
+					}
+					if source != "" {
+						query += "&source=" + url.QueryEscape(source)
+					}
+					call("GET", endpoint+query, "")
 				},
-			})
+			}
+			listCmd.Flags().StringSlice("tag", nil, "Only items with all of these tags (comma separated)")
+			listCmd.Flags().String("source", "", "Only items found by this source")
+			addSeenFilterFlags(listCmd)
+			cmd.AddCommand(listCmd)
 		} else if action == "count" {
 			cmd.AddCommand(&cobra.Command{
 				Use:     action,
@@ -277,7 +393,7 @@ func createCRUDCommand(name, dataKey string, endpoints map[string]string) *cobra
 				},
 			})
 		} else {
-			cmd.AddCommand(&cobra.Command{
+			actionCmd := &cobra.Command{
 				Use:   fmt.Sprintf("%s [items...]", action),
 				Short: fmt.Sprintf("%s %s %s", actionEmoji, strings.Title(action), name+"s"),
 				Long: fmt.Sprintf(`%s %s %s. Supports:
@@ -285,9 +401,9 @@ func createCRUDCommand(name, dataKey string, endpoints map[string]string) *cobra
 %s Stdin: echo 'item' | bbrf company %s %s -
 %s File: bbrf company %s %s @file.txt
 
-Scope Filtering (for domains):
+Scope Filtering (for domains, IPs and ASNs):
 %s Enabled by default with --scope-filter=true
-%s Use --allow-out-of-scope to include out-of-scope domains
+%s Use --allow-out-of-scope to include out-of-scope items
 %s Use --verbose-scope for detailed filtering info`,
 					actionEmoji, strings.Title(action), name+"s",
 					info("•"), name, action,
@@ -303,24 +419,70 @@ Scope Filtering (for domains):
   # %s %s from stdin
   cat items.txt | bbrf company %s %s - -c acme
 
-  # %s %s with verbose scope filtering (domains only)
+  # %s %s with verbose scope filtering
   bbrf company %s %s @items.txt -c acme --verbose-scope`,
 					strings.Title(action), name+"s", name, action,
 					strings.Title(action), name+"s", name, action,
 					strings.Title(action), name+"s", name, action,
 					strings.Title(action), name+"s", name, action),
 				Run: func(cmd *cobra.Command, args []string) {
-					if enableScopeFilter && name == "domain" {
+					if enableScopeFilter && action == "add" && (name == "domain" || name == "ip" || name == "asn") {
 						fmt.Printf("%s %s %s for: %s (with scope filtering)\n", info(actionEmoji), info(strings.Title(action)), info(name+"s"), info(company))
 					} else {
 						fmt.Printf("%s %s %s for: %s\n", info(actionEmoji), info(strings.Title(action)), info(name+"s"), info(company))
 					}
-					handleInputAndPost(endpoint, company, dataKey, args)
+					if action != "add" {
+						// Removals aren't scope filtered, so items that fell
+						// out of scope can still be deleted
+						postInput(endpoint, company, dataKey, readInput(args), nil)
+						return
+					}
+
+					source, _ := cmd.Flags().GetString("source")
+					from, _ := cmd.Flags().GetString("from")
+					if source == "" {
+						source = from
+					}
+					fields := map[string]string{"seen": seenNow()}
+					if source != "" {
+						fields["source"] = source
+					}
+
+					value := readInput(args)
+					if from != "" {
+						value = toolOutputItems(name, value)
+					}
+					filterAndPost(endpoint, company, dataKey, value, fields)
 				},
-			})
+			}
+			if action == "add" {
+				actionCmd.Flags().String("source", "", "Tool or person that found the items (default: --from)")
+				actionCmd.Flags().String("from", "", fmt.Sprintf("Read the output of this tool, plain or JSON lines, taking the %s from it", name+"s"))
+				actionCmd.Example += fmt.Sprintf(`
+
+  # Record which tool found the %s
+  subfinder -d example.com | bbrf company %s add - --source subfinder -c acme
+
+  # Read JSON tool output, recording the tool as source
+  subfinder -d example.com -oJ | bbrf company %s add - --from subfinder -c acme`, name+"s", name, name)
+			}
+			cmd.AddCommand(actionCmd)
 		}
 	}
 
+	if name == "domain" {
+		cmd.AddCommand(createDomainRootsCommand(endpoints["list"]))
+	}
+
+	if endpoints["tag"] != "" {
+		cmd.AddCommand(createTagCommand(name, dataKey, endpoints["tag"], endpoints["untag"]))
+	}
+
+	// Scope-filtered resources can be pruned when the scope changes
+	if decide, ok := scopeDeciders[name]; ok {
+		cmd.AddCommand(createPruneCommand(name, dataKey, endpoints["list"], endpoints["remove"], decide))
+	}
+
 	return cmd
 }
 
@@ -335,6 +497,15 @@ func createScopeCommand() *cobra.Command {
   # Add domains to out-of-scope from file
   bbrf company scope outscope @outscope.txt -c acme
 
+  # Add CIDRs and IP ranges to scope
+  bbrf company scope inscope 10.0.0.0/24 192.168.1.10-192.168.1.20 -c acme
+
+  # Add everything announced by an ASN to scope
+  bbrf company scope inscope AS13335 -c acme
+
+  # Wildcard with an exception, an exact-only host and a regex
+  bbrf company scope inscope "*.example.com" "!*.corp.example.com" "=example.org" 're:dev[0-9]+\.example\.net' -c acme
+
   # Show in-scope domains
   bbrf company scope show in -c acme
 
@@ -375,7 +546,8 @@ func createScopeCommand() *cobra.Command {
 				}
 
 				fmt.Println(info(fmt.Sprintf("%s %s for: %s", config.emoji, config.short, company)))
-				handleInputAndPost(config.endpoint, company, "domains", args)
+				handleInputAndPost(config.endpoint, company, "domains", args, nil)
+				scopeChanged(company, "scope "+action)
 			},
 		})
 	}
@@ -405,26 +577,125 @@ func createScopeCommand() *cobra.Command {
 		},
 	})
 
+	// Add lint command for finding mistakes in the scope lists
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "🧹 Find redundant, overlapping and contradictory scope rules",
+		Long: `Check the in- and out-of-scope lists for:
+  • duplicates: the same or an equivalent pattern listed twice
+  • subsumed:   a pattern whose matches are all covered by a broader one
+  • negated:    an in-scope pattern that is entirely out of scope or excluded
+  • malformed:  a pattern that can't match what it looks like it should
+  • public-suffix: an in-scope pattern covering a whole public suffix, like
+    co.uk or *.github.io, which is ignored when matching
+
+Exits with status 1 when problems are found.`,
+		Example: `  # Lint the scope of a company
+  bbrf company scope lint -c acme
+
+  # Machine readable output
+  bbrf company scope lint --json -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			scopeManager := NewScopeManager(company)
+			if err := scopeManager.LoadScope(); err != nil {
+				fmt.Println(errorC("❌ Failed to load scope rules: " + err.Error()))
+				os.Exit(1)
+			}
+
+			issues := lintScope(scopeManager.InScope, scopeManager.OutScope)
+
+			if jsonOutput {
+				if issues == nil {
+					issues = []LintIssue{}
+				}
+				output, _ := json.MarshalIndent(issues, "", "  ")
+				fmt.Println(string(output))
+			} else {
+				fmt.Println(info(fmt.Sprintf("🧹 Linting %d in-scope and %d out-of-scope patterns for: %s",
+					len(scopeManager.InScope), len(scopeManager.OutScope), company)))
+				fmt.Println()
+				for _, issue := range issues {
+					fmt.Println(formatLintIssue(issue))
+				}
+				if len(issues) == 0 {
+					fmt.Println(success("✅ No problems found"))
+				} else {
+					fmt.Println(count(fmt.Sprintf("\n📊 Total: %d problems", len(issues))))
+				}
+			}
+
+			if len(issues) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	lintCmd.Flags().Bool("json", false, "Print problems as JSON")
+	scopeCmd.AddCommand(lintCmd)
+
+	// Add scope-as-code commands
+	scopeCmd.AddCommand(createScopeFileCommands()...)
+
 	// Add test command for debugging scope filtering
-	scopeCmd.AddCommand(&cobra.Command{
-		Use:   "test <domain>",
-		Short: "🧪 Test if a domain matches scope rules",
+	testCmd := &cobra.Command{
+		Use:   "test <domain|ip|asn|url>",
+		Short: "🧪 Test if a domain, IP, ASN or URL matches scope rules",
 		Example: `  # Test if a domain is in scope
   bbrf company scope test example.com -c acme
 
   # Test multiple domains
-  bbrf company scope test example.com sub.example.com -c acme`,
+  bbrf company scope test example.com sub.example.com -c acme
+
+  # Test an IP against CIDR and range rules
+  bbrf company scope test 10.0.0.5 -c acme
+
+  # Test an ASN
+  bbrf company scope test AS13335 -c acme
+
+  # Test a URL against URL rules and its host
+  bbrf company scope test https://example.com/api/users -c acme
+
+  # Show which rule decided and how it matched
+  bbrf company scope test api.example.com --explain -c acme
+
+  # Machine readable decisions
+  bbrf company scope test api.example.com --json -c acme
+
+  # Test against the cached scope without contacting the server
+  bbrf company scope test api.example.com --offline -c acme`,
 		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println(info(fmt.Sprintf("🧪 Testing scope for company: %s", company)))
+			explain, _ := cmd.Flags().GetBool("explain")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			offline, _ := cmd.Flags().GetBool("offline")
 
 			scopeManager := NewScopeManager(company)
-			err := scopeManager.LoadScope()
+			var err error
+			if offline {
+				err = scopeManager.LoadCachedScope()
+			} else {
+				err = scopeManager.LoadScope()
+			}
 			if err != nil {
-				fmt.Println(errorC("❌ Failed to load scope rules: " + err.Error()))
+				// Same status as scope filter, so scripts can tell a failure
+				// from a result
+				fmt.Fprintln(os.Stderr, errorC("❌ Failed to load scope rules: "+err.Error()))
+				os.Exit(filterFailed)
+			}
+
+			decisions := make([]ScopeDecision, 0, len(args))
+			for _, item := range args {
+				decisions = append(decisions, scopeManager.Decide(item))
+			}
+
+			if jsonOutput {
+				output, _ := json.MarshalIndent(decisions, "", "  ")
+				fmt.Println(string(output))
 				return
 			}
 
+			fmt.Println(info(fmt.Sprintf("🧪 Testing scope for company: %s", company)))
 			fmt.Printf("%s Loaded %d in-scope and %d out-of-scope patterns\n",
 				info("ℹ️"), len(scopeManager.InScope), len(scopeManager.OutScope))
 
@@ -437,180 +708,350 @@ func createScopeCommand() *cobra.Command {
 
 			fmt.Println()
 
-			for _, domain := range args {
-				shouldAccept, reason := scopeManager.ShouldAcceptDomain(domain)
-				if shouldAccept {
-					fmt.Printf("%s %s - %s\n", success("✅ ACCEPT:"), domainClr(domain), info(reason))
+			for _, decision := range decisions {
+				if decision.Accepted {
+					fmt.Printf("%s %s - %s\n", success("✅ ACCEPT:"), domainClr(decision.Item), info(decision.Detail()))
 				} else {
-					fmt.Printf("%s %s - %s\n", errorC("❌ REJECT:"), domainClr(domain), warning(reason))
+					fmt.Printf("%s %s - %s\n", errorC("❌ REJECT:"), domainClr(decision.Item), warning(decision.Detail()))
+				}
+				if explain {
+					printScopeExplanation(decision)
 				}
 			}
 		},
+	}
+	testCmd.Flags().Bool("explain", false, "Show the rule, pattern and match type behind each decision")
+	testCmd.Flags().Bool("json", false, "Print decisions as JSON")
+	testCmd.Flags().Bool("offline", false, "Use the cached scope only, however old")
+	scopeCmd.AddCommand(testCmd)
+
+	scopeCmd.AddCommand(createScopeFilterCommand())
+	scopeCmd.AddCommand(createScopeHistoryCommand())
+
+	// Add refresh command for the local scope cache
+	scopeCmd.AddCommand(&cobra.Command{
+		Use:   "refresh",
+		Short: "🔄 Refresh the locally cached scope rules",
+		Long: `Fetch the scope rules from the server and store them in the local cache.
+
+Scope rules are cached per company and server under ~/.bbrf/cache/scope/ and
+reused for --scope-cache-ttl (default 5m) before the server is asked again,
+with an ETag so unchanged rules aren't downloaded twice. Changing the scope
+through bbrf drops the cache; refresh after changing it anywhere else.`,
+		Example: `  # Refresh the cached scope of a company
+  bbrf company scope refresh -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			scopeManager := NewScopeManager(company)
+			if err := scopeManager.RefreshScope(); err != nil {
+				fmt.Println(errorC("❌ Failed to refresh scope rules: " + err.Error()))
+				os.Exit(1)
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Cached %d in-scope and %d out-of-scope patterns for %s",
+				len(scopeManager.InScope), len(scopeManager.OutScope), company)))
+			fmt.Printf("%s %s\n", info("📁"), scopeCachePath(company))
+		},
 	})
 
 	return scopeCmd
 }
 
-// Scope Manager Methods
-func NewScopeManager(company string) *ScopeManager {
-	return &ScopeManager{
-		InScope:  make([]string, 0),
-		OutScope: make([]string, 0),
-		company:  company,
-	}
-}
+// Scope-as-code commands: export the server scope to a file, diff and apply it
+func createScopeFileCommands() []*cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "📤 Export scope as YAML or for other tools",
+		Long: `Export the scope of a company. Formats:
+  yaml    scope file for scope apply (default)
+  burp    Burp Suite project options with include/exclude host regexes
+  regex   one combined regex; --list out gives the regex of what to exclude
+  nuclei  plain target list, including CIDRs
+  hosts   plain host list
+
+For nuclei and hosts, wildcards are reduced to their base domain and regex
+patterns are skipped.`,
+		Example: `  # Print the scope as YAML
+  bbrf company scope export -c acme
+
+  # Write it to a file to keep in git
+  bbrf company scope export -o scope.yaml -c acme
+
+  # Burp Suite project options (Project > Load project options)
+  bbrf company scope export --format burp -o burp-scope.json -c acme
+
+  # Filter tool output with the scope regexes
+  cat hosts.txt | grep -P "$(bbrf company scope export --format regex -c acme)" \
+    | grep -vP "$(bbrf company scope export --format regex --list out -c acme)"
+
+  # Targets for nuclei
+  bbrf company scope export --format nuclei -c acme | nuclei -l -`,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+			format, _ := cmd.Flags().GetString("format")
+			list, _ := cmd.Flags().GetString("list")
 
-// LoadScope loads scope rules from the server
-func (sm *ScopeManager) LoadScope() error {
-	// Load in-scope patterns
-	inscope, err := sm.fetchScopeFromServer("in")
-	if err == nil {
-		sm.InScope = inscope
-	}
+			if list != "in" && list != "out" {
+				fmt.Println(errorC("❌ List must be 'in' or 'out'"))
+				os.Exit(1)
+			}
 
-	// Load out-scope patterns
-	outscope, err := sm.fetchScopeFromServer("out")
-	if err == nil {
-		sm.OutScope = outscope
-	}
+			scopeFile, err := fetchScopeFile(company)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to fetch scope: " + err.Error()))
+				os.Exit(1)
+			}
 
-	return nil
-}
+			var content []byte
+			var skipped []string
+			if format == "yaml" {
+				content, err = scopeFile.Marshal()
+			} else {
+				scopeManager := NewScopeManager(company)
+				scopeManager.InScope = scopeFile.InScope
+				scopeManager.OutScope = scopeFile.OutScope
+				var rendered string
+				rendered, skipped, err = renderScopeExport(format, list, scopeManager)
+				content = []byte(rendered)
+			}
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to encode scope: " + err.Error()))
+				os.Exit(1)
+			}
 
-func (sm *ScopeManager) fetchScopeFromServer(scopeType string) ([]string, error) {
-	url := fmt.Sprintf("%s/api/scope/show?company=%s&type=%s", config.API, sm.company, scopeType)
+			// Keep stdout clean for piping, report skipped patterns on stderr
+			for _, reason := range skipped {
+				fmt.Fprintf(os.Stderr, "%s Skipped %s\n", warning("⚠️"), reason)
+			}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+			if output == "" || output == "-" {
+				fmt.Print(string(content))
+				return
+			}
+			if err := os.WriteFile(output, content, 0644); err != nil {
+				fmt.Println(errorC("❌ Failed to write file: " + err.Error()))
+				os.Exit(1)
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Exported %d in-scope and %d out-of-scope patterns to %s",
+				len(scopeFile.InScope), len(scopeFile.OutScope), output)))
+		},
 	}
+	exportCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	exportCmd.Flags().String("format", "yaml", "Output format: "+strings.Join(scopeExportFormats, ", "))
+	exportCmd.Flags().String("list", "in", "Scope list for the regex format: in or out")
+
+	// planFromFile loads the file and computes the plan against the server
+	planFromFile := func(cmd *cobra.Command) ScopePlan {
+		path, _ := cmd.Flags().GetString("file")
+		desired, err := loadScopeFile(path)
+		if err != nil {
+			fmt.Println(errorC("❌ " + err.Error()))
+			os.Exit(1)
+		}
 
-	req.Header.Set("Authorization", "Bearer "+config.Token)
-	resp, err := insecureClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+		if company == "" {
+			company = desired.Company
+		} else if desired.Company != "" && desired.Company != company {
+			fmt.Printf("%s File is for company %s, applying to %s\n", warning("⚠️"), desired.Company, company)
+		}
+		if company == "" {
+			fmt.Println(errorC("❌ No company given and none set in the file"))
+			os.Exit(1)
+		}
 
-	respData, _ := io.ReadAll(resp.Body)
+		current, err := fetchScopeFile(company)
+		if err != nil {
+			fmt.Println(errorC("❌ Failed to fetch scope: " + err.Error()))
+			os.Exit(1)
+		}
 
-	if resp.StatusCode != 200 {
-		return []string{}, nil
+		fmt.Println(info(fmt.Sprintf("📋 Comparing %s with the scope of: %s", path, company)))
+		fmt.Println()
+		return planScope(current, desired)
 	}
 
-	// Handle empty response
-	if len(strings.TrimSpace(string(respData))) == 0 {
-		return []string{}, nil
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "🔍 Show the changes scope apply would make",
+		Example: `  # Compare a scope file with the server
+  bbrf company scope diff -f scope.yaml -c acme`,
+		Annotations: map[string]string{companyAnnotation: companyOptional},
+		Run: func(cmd *cobra.Command, args []string) {
+			printScopePlan(planFromFile(cmd))
+		},
 	}
+	diffCmd.Flags().StringP("file", "f", "", "Scope file (YAML or JSON)")
+	diffCmd.MarkFlagRequired("file")
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "🚀 Make the server scope match a YAML file",
+		Long: `Compare a scope file with the scope on the server, print the plan and apply
+only the needed additions and removals after confirmation.
+
+File format:
+  company: acme
+  inscope:
+    - "*.example.com"
+    - 10.0.0.0/24
+  outscope:
+    - "*.corp.example.com"`,
+		Example: `  # Review and apply a scope file
+  bbrf company scope apply -f scope.yaml -c acme
+
+  # Apply without asking, e.g. from CI
+  bbrf company scope apply -f scope.yaml --yes`,
+		Annotations: map[string]string{companyAnnotation: companyOptional},
+		Run: func(cmd *cobra.Command, args []string) {
+			autoApprove, _ := cmd.Flags().GetBool("yes")
 
-	var patterns []string
+			plan := planFromFile(cmd)
+			printScopePlan(plan)
+			if plan.Empty() {
+				return
+			}
 
-	// Try to parse as JSON first
-	err = json.Unmarshal(respData, &patterns)
-	if err != nil {
-		// If JSON parsing fails, treat as plain text (split by lines)
-		text := strings.TrimSpace(string(respData))
-		if text != "" {
-			// Split by newlines and filter empty lines
-			lines := strings.Split(text, "\n")
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				if line != "" {
-					patterns = append(patterns, line)
-				}
+			fmt.Println()
+			if !autoApprove && !confirm("Apply these changes?") {
+				fmt.Println(warning("⚠️ Apply cancelled"))
+				return
 			}
-		}
+
+			if err := applyScopePlan(company, plan, "scope apply"); err != nil {
+				fmt.Println(errorC("❌ Apply failed: " + err.Error()))
+				os.Exit(1)
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Applied: %d added, %d removed", len(plan.Add), len(plan.Remove))))
+		},
 	}
+	applyCmd.Flags().StringP("file", "f", "", "Scope file (YAML or JSON)")
+	applyCmd.Flags().BoolP("yes", "y", false, "Apply without asking for confirmation")
+	applyCmd.MarkFlagRequired("file")
 
-	return patterns, nil
+	return []*cobra.Command{exportCmd, diffCmd, applyCmd, createScopeImportCommand()}
 }
 
-// ShouldAcceptDomain determines if a domain should be accepted based on scope rules
-func (sm *ScopeManager) ShouldAcceptDomain(domain string) (bool, string) {
-	domain = strings.ToLower(strings.TrimSpace(domain))
+// Import official program scope from a bug bounty platform export
+func createScopeImportCommand() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "📥 Import program scope from a HackerOne, Bugcrowd, Intigriti or YesWeHack export",
+		Long: `Import a program's official scope. Wildcard, URL, domain, CIDR, IP and ASN
+assets are mapped to scope rules; assets that are not eligible for submission
+go to out-of-scope. A preview is shown before anything is added, and rules
+already on the server are left alone.
+
+Supported formats:
+  h1         HackerOne structured scope CSV, or structured_scopes API JSON
+  bugcrowd   Bugcrowd target groups JSON, or a plain target list
+  intigriti  Intigriti program JSON
+  yeswehack  YesWeHack program JSON`,
+		Example: `  # Import a HackerOne scope CSV
+  bbrf company scope import --format h1 scopes.csv -c acme
+
+  # Preview only
+  bbrf company scope import --format bugcrowd targets.json --dry-run -c acme
+
+  # From stdin, without confirmation
+  cat program.json | bbrf company scope import --format yeswehack - -y -c acme`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, _ := cmd.Flags().GetString("format")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			autoApprove, _ := cmd.Flags().GetBool("yes")
 
-	// First check if it's explicitly out of scope
-	if sm.IsOutOfScope(domain) {
-		return false, "domain matches out-of-scope pattern"
-	}
+			parse, ok := scopeImportFormats[strings.ToLower(format)]
+			if !ok {
+				fmt.Println(errorC("❌ Unknown format: " + format + " (use h1, bugcrowd, intigriti or yeswehack)"))
+				os.Exit(1)
+			}
 
-	// Then check if it's in scope
-	if sm.IsInScope(domain) {
-		return true, "domain matches in-scope pattern"
-	}
+			content, err := readImportFile(args[0])
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to read file: " + err.Error()))
+				os.Exit(1)
+			}
+			assets, err := parse(content)
+			if err != nil {
+				fmt.Println(errorC("❌ " + err.Error()))
+				os.Exit(1)
+			}
 
-	// If no in-scope patterns are defined, default to accept
-	if len(sm.InScope) == 0 {
-		return true, "no in-scope patterns defined, accepting by default"
-	}
+			current, err := fetchScopeFile(company)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to fetch scope: " + err.Error()))
+				os.Exit(1)
+			}
 
-	// Domain doesn't match any in-scope pattern
-	return false, "domain does not match any in-scope pattern"
-}
+			desired := &ScopeFile{
+				Company:  company,
+				InScope:  append([]string{}, current.InScope...),
+				OutScope: append([]string{}, current.OutScope...),
+			}
 
-func (sm *ScopeManager) IsInScope(domain string) bool {
-	for _, pattern := range sm.InScope {
-		if sm.matchesPattern(domain, pattern) {
-			return true
-		}
-	}
-	return false
-}
+			fmt.Println(info(fmt.Sprintf("📥 Importing %d %s assets into: %s", len(assets), format, company)))
+			fmt.Println()
 
-func (sm *ScopeManager) IsOutOfScope(domain string) bool {
-	for _, pattern := range sm.OutScope {
-		if sm.matchesPattern(domain, pattern) {
-			return true
-		}
-	}
-	return false
-}
+			skipped := 0
+			for _, rule := range mapImportedAssets(assets) {
+				asset := fmt.Sprintf("%s %s", rule.Asset.AssetType, rule.Asset.Identifier)
+				if rule.Pattern == "" {
+					skipped++
+					fmt.Printf("  %s %-9s %-40s %s\n", warning("~"), "skip", strings.TrimSpace(asset), warning(rule.Note))
+					continue
+				}
 
-func (sm *ScopeManager) matchesPattern(domain, pattern string) bool {
-	pattern = strings.ToLower(strings.TrimSpace(pattern))
-	domain = strings.ToLower(strings.TrimSpace(domain))
+				list := "inscope"
+				if rule.Asset.InScope {
+					desired.InScope = append(desired.InScope, rule.Pattern)
+				} else {
+					list = "outscope"
+					desired.OutScope = append(desired.OutScope, rule.Pattern)
+				}
+				fmt.Printf("  %s %-9s %-40s %s\n", success("→"), list, domainClr(rule.Pattern), info(fmt.Sprintf("(%s) %s", rule.Note, strings.TrimSpace(asset))))
+			}
+			if skipped > 0 {
+				fmt.Printf("\n%s %d assets have no scope rule equivalent and were skipped\n", warning("⚠️"), skipped)
+			}
 
-	// Exact match
-	if pattern == domain {
-		return true
-	}
+			fmt.Println()
+			plan := planScope(current, desired)
+			printScopePlan(plan)
+			if plan.Empty() || dryRun {
+				return
+			}
 
-	// Wildcard pattern matching
-	if strings.Contains(pattern, "*") {
-		return sm.matchesWildcard(domain, pattern)
-	}
+			fmt.Println()
+			if !autoApprove && !confirm("Import these rules?") {
+				fmt.Println(warning("⚠️ Import cancelled"))
+				return
+			}
 
-	// Subdomain matching (implicit wildcard)
-	if strings.HasSuffix(domain, "."+pattern) {
-		return true
+			if err := applyScopePlan(company, plan, "scope import"); err != nil {
+				fmt.Println(errorC("❌ Import failed: " + err.Error()))
+				os.Exit(1)
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Imported %d scope rules", len(plan.Add))))
+		},
 	}
+	importCmd.Flags().String("format", "", "Export format: h1, bugcrowd, intigriti or yeswehack")
+	importCmd.Flags().Bool("dry-run", false, "Only show the preview")
+	importCmd.Flags().BoolP("yes", "y", false, "Import without asking for confirmation")
+	importCmd.MarkFlagRequired("format")
 
-	return false
+	return importCmd
 }
 
-func (sm *ScopeManager) matchesWildcard(domain, pattern string) bool {
-	// Handle *.example.com patterns
-	if strings.HasPrefix(pattern, "*.") {
-		suffix := pattern[2:] // Remove "*."
-		return domain == suffix || strings.HasSuffix(domain, "."+suffix)
-	}
-
-	// Handle other wildcard patterns like sub.*.example.com
-	// Convert wildcard pattern to regex
-	escapedPattern := regexp.QuoteMeta(pattern)
-	// Replace escaped \* with .* for regex matching
-	regexPattern := strings.ReplaceAll(escapedPattern, "\\*", ".*")
-	// Anchor the pattern
-	regexPattern = "^" + regexPattern + "$"
-
-	regex, err := regexp.Compile(regexPattern)
-	if err != nil {
-		// Fallback to simple glob matching
-		matched, _ := filepath.Match(pattern, domain)
-		return matched
+// printScopeExplanation renders the details of a decision below its summary line
+func printScopeExplanation(decision ScopeDecision) {
+	fmt.Printf("   %s %d. %s\n", info("Rule:      "), decision.Precedence, decision.Rule)
+	if decision.Pattern != "" {
+		list := "in-scope"
+		if decision.List == "out" {
+			list = "out-of-scope"
+		}
+		fmt.Printf("   %s %s (%s list)\n", info("Pattern:   "), data(decision.Pattern), list)
+		fmt.Printf("   %s %s\n", info("Match type:"), decision.MatchType)
 	}
-
-	return regex.MatchString(domain)
+	fmt.Println()
 }
 
 // Helper functions for emojis
@@ -622,6 +1063,10 @@ func getEmojiForResource(resource string) string {
 		return "🖥️"
 	case "asn":
 		return "🏢"
+	case "url":
+		return "🔗"
+	case "service":
+		return "🔌"
 	default:
 		return "📋"
 	}
@@ -701,7 +1146,9 @@ func doLogin() {
 	fmt.Println(success("✅ Login successful and token saved!"))
 }
 
-func handleInputAndPost(path, company, key string, args []string) {
+// readInput reads the items of an input command: "-" for stdin, @file (or a
+// .txt file), or the arguments themselves
+func readInput(args []string) string {
 	if len(args) < 1 {
 		fmt.Println(errorC("❌ No input provided"))
 		os.Exit(1)
@@ -730,6 +1177,23 @@ func handleInputAndPost(path, company, key string, args []string) {
 		value = strings.Join(args, " ")
 	}
 
+	return value
+}
+
+// handleInputAndPost reads items like readInput, scope filters domains, IPs
+// and ASNs, and posts them under key along with any extra fields
+func handleInputAndPost(path, company, key string, args []string, fields map[string]string) {
+	filterAndPost(path, company, key, readInput(args), fields)
+}
+
+// filterAndPost scope filters the space separated items of an add like
+// handleInputAndPost and posts them
+func filterAndPost(path, company, key, value string, fields map[string]string) {
+	// A public suffix in scope would cover every domain under it
+	if path == "/api/scope/in" {
+		value = dropPublicSuffixPatterns(value)
+	}
+
 	// Apply scope filtering for domain operations
 	if enableScopeFilter && !allowOutOfScope && key == "domains" {
 		// fmt.Printf("%s Applying scope filtering...\n", info("🔍"))
@@ -745,17 +1209,24 @@ func handleInputAndPost(path, company, key string, args []string) {
 		// if originalValue != value {
 		// 	fmt.Printf("%s Scope filtering applied successfully\n", info("✅"))
 		// }
-	} else if key == "domains" {
-		fmt.Printf("%s Scope filtering is DISABLED or bypassed\n", warning("⚠️"))
-		if !enableScopeFilter {
-			fmt.Printf("   - Reason: scope-filter flag is false\n")
-		}
-		if allowOutOfScope {
-			fmt.Printf("   - Reason: allow-out-of-scope flag is true\n")
-		}
+	} else if enableScopeFilter && !allowOutOfScope && key == "ips" {
+		value = filterIPsBeforePost(company, value)
+	} else if enableScopeFilter && !allowOutOfScope && key == "asns" {
+		value = filterASNsBeforePost(company, value)
+	} else if key == "domains" || key == "ips" || key == "asns" {
+		printScopeFilterBypassed()
 	}
 
+	postInput(path, company, key, value, fields)
+}
+
+// postInput posts space separated items under key, along with any extra
+// fields
+func postInput(path, company, key, value string, fields map[string]string) {
 	body := map[string]string{"company": company, key: value}
+	for field, fieldValue := range fields {
+		body[field] = fieldValue
+	}
 	jsonBody, _ := json.Marshal(body)
 
 	call("POST", path, string(jsonBody))
@@ -766,12 +1237,8 @@ func filterDomainsBeforePost(company, domainsInput string) string {
 		fmt.Printf("%s Loading scope rules for filtering...\n", info("🔍"))
 	}
 
-	scopeManager := NewScopeManager(company)
-	err := scopeManager.LoadScope()
-	if err != nil {
-		if verboseScope {
-			fmt.Printf("%s Could not load scope rules, proceeding without filtering\n", warning("⚠️"))
-		}
+	scopeManager, ok := loadScopeForFiltering(company)
+	if !ok {
 		return domainsInput
 	}
 
@@ -794,24 +1261,22 @@ func filterDomainsBeforePost(company, domainsInput string) string {
 
 	// fmt.Printf("%s Processing %d domains for scope filtering...\n", info("ℹ️"), len(domains))
 
-	for _, domain := range domains {
-		domain = strings.TrimSpace(domain)
-		if domain == "" {
-			continue
-		}
-
+	decisions := evaluateScope(domains, func(domain string) ScopeDecision {
 		// Extract domain from domain:ip format if present
 		cleanDomain := strings.Split(domain, ":")[0]
+		return scopeManager.ShouldAcceptDomain(cleanDomain)
+	})
 
-		shouldAccept, reason := scopeManager.ShouldAcceptDomain(cleanDomain)
-		if shouldAccept {
+	for i, decision := range decisions {
+		domain := domains[i]
+		if decision.Accepted {
 			acceptedDomains = append(acceptedDomains, domain)
 			if verboseScope {
-				fmt.Printf("%s %s - %s\n", success("✅ ACCEPTED:"), domainClr(domain), reason)
+				fmt.Printf("%s %s - %s\n", success("✅ ACCEPTED:"), domainClr(domain), decision.Detail())
 			}
 		} else {
 			rejectedCount++
-			// fmt.Printf("%s %s - %s\n", errorC("❌ REJECTED:"), domainClr(domain), reason)
+			// fmt.Printf("%s %s - %s\n", errorC("❌ REJECTED:"), domainClr(domain), decision.Detail())
 		}
 	}
 
@@ -833,7 +1298,173 @@ func filterDomainsBeforePost(company, domainsInput string) string {
 	return strings.Join(acceptedDomains, " ")
 }
 
+// printScopeFilterBypassed explains why an add isn't scope filtered
+func printScopeFilterBypassed() {
+	fmt.Printf("%s Scope filtering is DISABLED or bypassed\n", warning("⚠️"))
+	if !enableScopeFilter {
+		fmt.Printf("   - Reason: scope-filter flag is false\n")
+	}
+	if allowOutOfScope {
+		fmt.Printf("   - Reason: allow-out-of-scope flag is true\n")
+	}
+}
+
+// dropPublicSuffixPatterns removes in-scope patterns that cover a whole public
+// suffix, like co.uk or *.com, and exits if nothing is left
+func dropPublicSuffixPatterns(input string) string {
+	var kept []string
+	for _, pattern := range strings.Fields(input) {
+		if publicSuffixPattern(pattern) {
+			fmt.Printf("%s %s - would put every domain under a public suffix in scope\n", errorC("❌ REJECTED:"), domainClr(pattern))
+			continue
+		}
+		kept = append(kept, pattern)
+	}
+	if len(kept) == 0 {
+		fmt.Printf("%s No patterns left to add\n", warning("⚠️"))
+		os.Exit(1)
+	}
+	return strings.Join(kept, " ")
+}
+
+// scopeStrictMode reports whether adds must fail closed. --scope-strict
+// overrides scope_strict from the config file.
+func scopeStrictMode() bool {
+	if rootCmd.PersistentFlags().Changed("scope-strict") {
+		return scopeStrict
+	}
+	return config.ScopeStrict
+}
+
+// loadScopeForFiltering loads the scope rules used to filter an add. When they
+// can't be loaded, strict mode aborts the add and otherwise filtering is
+// skipped with a warning; ok is false in that case.
+func loadScopeForFiltering(company string) (*ScopeManager, bool) {
+	scopeManager := NewScopeManager(company)
+	if err := scopeManager.LoadScope(); err != nil {
+		if scopeStrictMode() {
+			fmt.Printf("%s Scope rules could not be loaded: %s\n", errorC("❌"), err.Error())
+			fmt.Printf("%s Strict scope mode is on, nothing was added\n", errorC("❌"))
+			os.Exit(1)
+		}
+		fmt.Printf("%s Scope rules could not be loaded (%s), proceeding without filtering\n", warning("⚠️"), err.Error())
+		return nil, false
+	}
+	return scopeManager, true
+}
+
+func filterIPsBeforePost(company, ipsInput string) string {
+	return filterItemsBeforePost(company, ipsInput, "IPs", countIPRules,
+		func(sm *ScopeManager, item string) ScopeDecision {
+			return sm.ShouldAcceptIP(extractIP(item))
+		})
+}
+
+func filterASNsBeforePost(company, asnsInput string) string {
+	return filterItemsBeforePost(company, asnsInput, "ASNs", countASNRules,
+		func(sm *ScopeManager, item string) ScopeDecision {
+			return sm.ShouldAcceptASN(item)
+		})
+}
+
+// filterItemsBeforePost applies a ScopeManager decision to every whitespace
+// separated item of the input and returns the accepted items
+func filterItemsBeforePost(company, input, noun string, countRules func([]string) int,
+	accept func(*ScopeManager, string) ScopeDecision) string {
+	if verboseScope {
+		fmt.Printf("%s Loading scope rules for filtering...\n", info("🔍"))
+	}
+
+	scopeManager, ok := loadScopeForFiltering(company)
+	if !ok {
+		return input
+	}
+
+	if verboseScope {
+		fmt.Printf("%s Loaded %d in-scope and %d out-of-scope rules for %s\n",
+			info("ℹ️"), countRules(scopeManager.InScope), countRules(scopeManager.OutScope), noun)
+	}
+
+	items := strings.Fields(input)
+	var acceptedItems []string
+
+	decisions := evaluateScope(items, func(item string) ScopeDecision {
+		return accept(scopeManager, item)
+	})
+
+	for i, decision := range decisions {
+		item := items[i]
+		if decision.Accepted {
+			acceptedItems = append(acceptedItems, item)
+			if verboseScope {
+				fmt.Printf("%s %s - %s\n", success("✅ ACCEPTED:"), domainClr(item), decision.Detail())
+			}
+		} else if verboseScope {
+			fmt.Printf("%s %s - %s\n", errorC("❌ REJECTED:"), domainClr(item), decision.Detail())
+		}
+	}
+
+	if len(acceptedItems) != len(items) {
+		fmt.Printf("%s %d/%d %s will be added\n",
+			info("ℹ️"), len(acceptedItems), len(items), noun)
+	}
+
+	if len(acceptedItems) == 0 {
+		fmt.Printf("%s No %s passed scope filtering! Nothing will be added.\n", warning("⚠️"), noun)
+		return ""
+	}
+
+	return strings.Join(acceptedItems, " ")
+}
+
+// apiRequest sends a JSON request to the API and returns the response body.
+// Unlike call it doesn't print anything, and reports HTTP errors as errors.
+func apiRequest(method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewBuffer(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, config.API+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+
+	resp, err := insecureClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return respData, fmt.Errorf("API error (%d): %s", resp.StatusCode, strings.TrimSpace(string(respData)))
+	}
+	return respData, nil
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no
+func confirm(question string) bool {
+	fmt.Print(prompt(question + " (y/N): "))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 func call(method, path, body string) {
+	checkOutputFormat()
+
 	url := config.API + path
 	var req *http.Request
 	var err error
@@ -866,21 +1497,18 @@ func call(method, path, body string) {
 		return
 	}
 
-	// Special handling for company list
-	if strings.HasSuffix(path, "/api/company/list") {
-		var companies []string
-		if err := json.Unmarshal(respData, &companies); err != nil {
-			fmt.Println(errorC("❌ Failed to parse company list: " + err.Error()))
-			return
-		}
+	switch outputFormat {
+	case "json":
+		renderJSON(respData)
+		return
+	case "csv":
+		renderCSV(respData)
+		return
+	}
 
-		fmt.Println(header(" 🏢 Companies "))
-		for i, c := range companies {
-			fmt.Printf("%s %s\n",
-				warning(fmt.Sprintf("%d.", i+1)),
-				domainClr(c))
-		}
-		fmt.Println(count(fmt.Sprintf("\n📊 Total: %d companies", len(companies))))
+	// Special handling for company list
+	if endpoint, _, _ := strings.Cut(path, "?"); strings.HasSuffix(endpoint, companyListEndpoint) {
+		printCompanies(respData)
 		return
 	}
 
@@ -894,6 +1522,10 @@ func call(method, path, body string) {
 		case float64:
 			fmt.Println(count(fmt.Sprintf("📊 Count: %.0f", v)))
 		case []interface{}:
+			if isRecordList(v) {
+				renderRecordTable(v)
+				return
+			}
 			fmt.Println(header(" 📋 Results "))
 			for i, item := range v {
 				fmt.Printf("%s %s\n",
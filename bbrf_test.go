@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestResolveCompany(t *testing.T) {
+	defer func(old string) { company = old }(company)
+	companyCmd := createCompanyCommands()
+
+	tests := []struct {
+		args        []string
+		flag        string
+		want        string
+		wantResolve bool
+	}{
+		{[]string{"add", "acme"}, "", "acme", true},
+		{[]string{"remove", "acme"}, "", "acme", true},
+		{[]string{"info", "acme"}, "", "acme", true},
+		{[]string{"set", "acme", "bounty", "vdp"}, "", "acme", true},
+		{[]string{"info", "other"}, "acme", "acme", true},
+		// The first argument of these is not a company
+		{[]string{"show", "*.example.com"}, "", "", false},
+		{[]string{"scope", "import", "program.json"}, "", "", false},
+		{[]string{"scope", "filter"}, "", "", false},
+		{[]string{"finding", "add", "XSS"}, "", "", false},
+		{[]string{"domain", "tag", "add", "prod", "a.example.com"}, "", "", false},
+		{[]string{"scope", "import", "program.json"}, "acme", "acme", true},
+		// These find the company themselves
+		{[]string{"scope", "apply"}, "", "", true},
+		{[]string{"merge", "acquired-co"}, "", "", true},
+		{[]string{"import", "acme.tar.gz"}, "", "", true},
+	}
+	for _, tt := range tests {
+		cmd, args, err := companyCmd.Find(tt.args)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		company = tt.flag
+		if got := resolveCompany(cmd, args); got != tt.wantResolve || company != tt.want {
+			t.Errorf("%v with -c %q: got %v, company %q, want %v, %q", tt.args, tt.flag, got, company, tt.wantResolve, tt.want)
+		}
+	}
+}
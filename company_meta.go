@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Company metadata endpoints. The list endpoint returns metadata along with
+// each company when asked with detail=true.
+const (
+	companyMetaEndpoint = "/api/company/meta"
+	companyInfoEndpoint = "/api/company/info"
+	companyListEndpoint = "/api/company/list"
+)
+
+// companyMetaKeys are the metadata keys company set accepts, with their
+// allowed values; a nil list allows any value
+var companyMetaKeys = map[string][]string{
+	"platform":   {"h1", "bugcrowd", "intigriti", "yeswehack", "self-hosted", "other"},
+	"url":        nil,
+	"bounty":     {"paid", "vdp", "points"},
+	"visibility": {"public", "private"},
+	"status":     {"active", "paused", "closed"},
+}
+
+// CompanyInfo is a company with its metadata
+type CompanyInfo struct {
+	Company  string            `json:"company"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// createCompanySetCommand builds "company set", which stores one metadata
+// value on the server
+func createCompanySetCommand() *cobra.Command {
+	var keys []string
+	for key := range companyMetaKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var allowed []string
+	for _, key := range keys {
+		if values := companyMetaKeys[key]; values != nil {
+			allowed = append(allowed, fmt.Sprintf("  %-11s %s", key, strings.Join(values, ", ")))
+		} else {
+			allowed = append(allowed, fmt.Sprintf("  %-11s any value", key))
+		}
+	}
+
+	return &cobra.Command{
+		Use:   "set [company] <key> <value>",
+		Short: "🏷️  Set program metadata",
+		Long: `Store program metadata on the server. An empty value removes the key.
+
+Keys and values:
+` + strings.Join(allowed, "\n"),
+		Example: `  bbrf company set platform h1 -c acme
+  bbrf company set url https://hackerone.com/acme -c acme
+  bbrf company set bounty vdp -c acme
+  bbrf company set status paused -c acme
+
+  # Company as first argument
+  bbrf company set acme visibility private`,
+		Annotations: map[string]string{companyAnnotation: companyFromArg},
+		Args:        cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 3 {
+				if cmd.Flags().Changed("company") {
+					fmt.Println(errorC("❌ Give the company either with -c or as the first argument"))
+					os.Exit(1)
+				}
+				args = args[1:]
+			} else if !cmd.Flags().Changed("company") {
+				fmt.Println(errorC("❌ No company given, use -c"))
+				os.Exit(1)
+			}
+
+			key, value := strings.ToLower(args[0]), strings.TrimSpace(args[1])
+			values, ok := companyMetaKeys[key]
+			if !ok {
+				fmt.Println(errorC("❌ Unknown key: " + key + " (use " + strings.Join(keys, ", ") + ")"))
+				os.Exit(1)
+			}
+			if values != nil && value != "" {
+				value = strings.ToLower(value)
+				if !slices.Contains(values, value) {
+					fmt.Println(errorC(fmt.Sprintf("❌ Invalid %s: %s (use %s)", key, value, strings.Join(values, ", "))))
+					os.Exit(1)
+				}
+			}
+
+			body := map[string]string{"company": company, "key": key, "value": value}
+			if _, err := apiRequest("POST", companyMetaEndpoint, body); err != nil {
+				fmt.Println(errorC("❌ Failed to set metadata: " + err.Error()))
+				os.Exit(1)
+			}
+			if value == "" {
+				fmt.Println(success(fmt.Sprintf("✅ Removed %s from %s", key, company)))
+			} else {
+				fmt.Println(success(fmt.Sprintf("✅ Set %s of %s to %s", key, company, value)))
+			}
+		},
+	}
+}
+
+// createCompanyInfoCommand builds "company info", showing the metadata and
+// item counts of a company
+func createCompanyInfoCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "info",
+		Short: "ℹ️  Show program metadata and item counts",
+		Example: `  bbrf company info -c acme
+  bbrf company info acme`,
+		Annotations: map[string]string{companyAnnotation: companyFromArg},
+		Run: func(cmd *cobra.Command, args []string) {
+			checkOutputFormat()
+
+			respData, err := apiRequest("GET", companyInfoEndpoint+"?company="+url.QueryEscape(company), nil)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to get company info: " + err.Error()))
+				os.Exit(1)
+			}
+			var companyInfo CompanyInfo
+			if err := json.Unmarshal(respData, &companyInfo); err != nil {
+				fmt.Println(errorC("❌ Failed to parse company info: " + err.Error()))
+				os.Exit(1)
+			}
+
+			counts := make(map[string]string)
+			for _, resource := range statsResources {
+				if respData, err := apiRequest("GET", resource.countEndpoint+"?company="+url.QueryEscape(company), nil); err == nil {
+					counts[resource.name] = strings.TrimSpace(string(respData))
+				}
+			}
+
+			switch outputFormat {
+			case "json":
+				out, _ := json.MarshalIndent(map[string]interface{}{
+					"company":  company,
+					"metadata": companyInfo.Metadata,
+					"counts":   counts,
+				}, "", "  ")
+				fmt.Println(string(out))
+				return
+			case "csv":
+				rows := [][]string{}
+				for _, key := range sortedKeys(companyInfo.Metadata) {
+					rows = append(rows, []string{key, companyInfo.Metadata[key]})
+				}
+				for _, resource := range statsResources {
+					if total, ok := counts[resource.name]; ok {
+						rows = append(rows, []string{resource.name, total})
+					}
+				}
+				writeCSV([]string{"key", "value"}, rows)
+				return
+			}
+
+			fmt.Println(header(" 🏢 " + company + " "))
+			if len(companyInfo.Metadata) == 0 {
+				fmt.Println(info("No metadata, add some with: bbrf company set <key> <value> -c " + company))
+			}
+			for _, key := range sortedKeys(companyInfo.Metadata) {
+				fmt.Printf("%s %s\n", info(fmt.Sprintf("%-11s", key+":")), data(companyInfo.Metadata[key]))
+			}
+			fmt.Println()
+			for _, resource := range statsResources {
+				if total, ok := counts[resource.name]; ok {
+					fmt.Printf("%s %s\n", info(fmt.Sprintf("%-11s", resource.name+":")), count(total))
+				}
+			}
+		},
+	}
+}
+
+// companiesQuery turns the companies filter flags into list query
+// parameters
+func companiesQuery(cmd *cobra.Command) (string, error) {
+	query := url.Values{"detail": {"true"}}
+	for _, key := range []string{"platform", "bounty", "visibility", "status"} {
+		value, _ := cmd.Flags().GetString(key)
+		if value == "" {
+			continue
+		}
+		value = strings.ToLower(value)
+		if !slices.Contains(companyMetaKeys[key], value) {
+			return "", fmt.Errorf("invalid --%s: %s (use %s)", key, value, strings.Join(companyMetaKeys[key], ", "))
+		}
+		query.Set(key, value)
+	}
+	if active, _ := cmd.Flags().GetBool("active"); active {
+		if query.Get("status") != "" && query.Get("status") != "active" {
+			return "", fmt.Errorf("--active conflicts with --status %s", query.Get("status"))
+		}
+		query.Set("status", "active")
+	}
+	return "?" + query.Encode(), nil
+}
+
+// addCompaniesFilterFlags adds the metadata filters of companies
+func addCompaniesFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("platform", "", "Only programs on this platform: "+strings.Join(companyMetaKeys["platform"], ", "))
+	cmd.Flags().String("bounty", "", "Only programs of this bounty type: "+strings.Join(companyMetaKeys["bounty"], ", "))
+	cmd.Flags().String("visibility", "", "Only public or private programs")
+	cmd.Flags().String("status", "", "Only programs with this status: "+strings.Join(companyMetaKeys["status"], ", "))
+	cmd.Flags().Bool("active", false, "Only active programs, same as --status active")
+}
+
+// printCompanies renders the company list, with metadata columns when the
+// server returned them
+func printCompanies(respData []byte) {
+	var companies []string
+	if err := json.Unmarshal(respData, &companies); err == nil {
+		fmt.Println(header(" 🏢 Companies "))
+		for i, c := range companies {
+			fmt.Printf("%s %s\n",
+				warning(fmt.Sprintf("%d.", i+1)),
+				domainClr(c))
+		}
+		fmt.Println(count(fmt.Sprintf("\n📊 Total: %d companies", len(companies))))
+		return
+	}
+
+	var records []interface{}
+	if err := json.Unmarshal(respData, &records); err != nil || (len(records) > 0 && !isRecordList(records)) {
+		fmt.Println(errorC("❌ Failed to parse company list: " + string(respData)))
+		return
+	}
+	columns, rows := recordRows(records)
+	if len(records) == 0 {
+		columns = []string{"company"}
+	}
+	printTable(" 🏢 Companies ", "companies", columns, rows)
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const companyRenameEndpoint = "/api/company/rename"
+
+// companyAssetResources are the assets copied between companies
+var companyAssetResources = []struct {
+	name, dataKey, listEndpoint, addEndpoint string
+}{
+	{"domain", "domains", "/api/domains", "/api/domains/add"},
+	{"ip", "ips", "/api/ip/list", "/api/ip"},
+	{"asn", "asns", "/api/asn/list", "/api/asn/add"},
+}
+
+// assetCopy is the part of a resource that would be copied into a company
+type assetCopy struct {
+	name, dataKey, addEndpoint string
+	add                        []string
+	existing                   int
+	rejected                   []string
+	reasons                    []string
+}
+
+// createCompanyRenameCommand builds "company rename --to"
+func createCompanyRenameCommand() *cobra.Command {
+	renameCmd := &cobra.Command{
+		Use:   "rename --to <name>",
+		Short: "✏️  Rename a company",
+		Long: `Rename a company on the server, keeping all of its data. The local scope
+history follows the new name.`,
+		Example: `  # Preview
+  bbrf company rename --to acme-corp --dry-run -c acme
+
+  # Rename without asking
+  bbrf company rename --to acme-corp -y -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			to, _ := cmd.Flags().GetString("to")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			autoApprove, _ := cmd.Flags().GetBool("yes")
+
+			mustCompanyPair(company, to, false)
+
+			fmt.Println(title(fmt.Sprintf("Rename %s → %s", company, to)))
+			for _, resource := range statsResources {
+				total := "?"
+				if respData, err := apiRequest("GET", resource.countEndpoint+"?company="+url.QueryEscape(company), nil); err == nil {
+					total = strings.TrimSpace(string(respData))
+				}
+				fmt.Printf("  %s %s %s\n", info("•"), total, resource.name)
+			}
+			scope := mustFetchScopeFile(company)
+			fmt.Printf("  %s %d in-scope and %d out-of-scope patterns\n", info("•"), len(scope.InScope), len(scope.OutScope))
+
+			if dryRun {
+				fmt.Println(info("ℹ️  Dry run, nothing renamed."))
+				return
+			}
+			if !autoApprove && !confirm(fmt.Sprintf("Rename %s to %s?", company, to)) {
+				fmt.Println(warning("⚠️ Rename cancelled"))
+				return
+			}
+
+			body := map[string]string{"company": company, "to": to}
+			if _, err := apiRequest("POST", companyRenameEndpoint, body); err != nil {
+				fmt.Println(errorC("❌ Failed to rename company: " + err.Error()))
+				os.Exit(1)
+			}
+			moveScopeHistory(company, to)
+			fmt.Println(success(fmt.Sprintf("✅ Renamed %s to %s", company, to)))
+		},
+	}
+	renameCmd.Flags().String("to", "", "New company name")
+	addCompanyOpFlags(renameCmd)
+
+	return renameCmd
+}
+
+// createCompanyCloneCommand builds "company clone --to"
+func createCompanyCloneCommand() *cobra.Command {
+	cloneCmd := &cobra.Command{
+		Use:   "clone --to <name>",
+		Short: "🧬 Copy a company's scope, and optionally assets, to a new company",
+		Example: `  # Preview a scope-only clone
+  bbrf company clone --to acme-staging --dry-run -c acme
+
+  # Copy scope, domains, IPs and ASNs
+  bbrf company clone --to acme-staging --assets -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			to, _ := cmd.Flags().GetString("to")
+			withAssets, _ := cmd.Flags().GetBool("assets")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			autoApprove, _ := cmd.Flags().GetBool("yes")
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+			mustCompanyPair(company, to, false)
+
+			scope := mustFetchScopeFile(company)
+			plan := planScope(&ScopeFile{}, scope)
+
+			var copies []assetCopy
+			if withAssets {
+				// Items passed the source's scope when they were added, and
+				// the clone has the same scope
+				copies = planAssetCopies(company, "", nil)
+			}
+
+			fmt.Println(title(fmt.Sprintf("Clone %s → %s (new company)", company, to)))
+			printScopePlan(plan)
+			printAssetCopies(copies)
+
+			if dryRun {
+				fmt.Println(info("ℹ️  Dry run, nothing copied."))
+				return
+			}
+			if !autoApprove && !confirm(fmt.Sprintf("Create %s as a copy of %s?", to, company)) {
+				fmt.Println(warning("⚠️ Clone cancelled"))
+				return
+			}
+
+			if _, err := apiRequest("POST", "/api/company", map[string]string{"company": to}); err != nil {
+				fmt.Println(errorC("❌ Failed to create company: " + err.Error()))
+				os.Exit(1)
+			}
+			cleanup := fmt.Sprintf("Remove it with 'bbrf company remove -c %s' and clone again", to)
+			if err := applyScopePlan(to, plan, "company clone"); err != nil {
+				fmt.Println(errorC("❌ Failed to copy scope: " + err.Error()))
+				exitUnfinished(fmt.Sprintf("%s was created, but its scope may be incomplete", to), copies, cleanup)
+			}
+			if remaining, err := copyAssets(to, copies, batchSize); err != nil {
+				fmt.Println(errorC("❌ Failed to copy assets: " + err.Error()))
+				exitUnfinished(fmt.Sprintf("%s was created with its scope, but not all assets", to), remaining, cleanup)
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Cloned %s to %s", company, to)))
+		},
+	}
+	cloneCmd.Flags().String("to", "", "Name of the new company")
+	cloneCmd.Flags().Bool("assets", false, "Also copy domains, IPs and ASNs")
+	cloneCmd.Flags().Int("batch-size", 500, "Items per add request")
+	addCompanyOpFlags(cloneCmd)
+
+	return cloneCmd
+}
+
+// createCompanyMergeCommand builds "company merge <src> --into <dst>"
+func createCompanyMergeCommand() *cobra.Command {
+	mergeCmd := &cobra.Command{
+		Use:   "merge <src> --into <dst>",
+		Short: "🔀 Merge a company's scope and assets into another",
+		Long: `Add the scope patterns, domains, IPs and ASNs of src that dst doesn't have
+to dst. src is left as it is; remove it afterwards if it is no longer needed.
+
+Conflicts are reported and resolved in favour of dst:
+  • a pattern in scope in one company and out of scope in the other keeps
+    dst's side
+  • assets of src rejected by the merged scope are not added, unless
+    --allow-out-of-scope is given`,
+		Example: `  # Preview
+  bbrf company merge acquired-co --into acme --dry-run
+
+  # Merge without asking
+  bbrf company merge acquired-co --into acme -y`,
+		Annotations: map[string]string{companyAnnotation: companyOptional},
+		Args:        cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			src := args[0]
+			into, _ := cmd.Flags().GetString("into")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			autoApprove, _ := cmd.Flags().GetBool("yes")
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+			if cmd.Flags().Changed("company") && company != src {
+				fmt.Println(errorC("❌ Give the source company as the argument, not with -c"))
+				os.Exit(1)
+			}
+			mustCompanyPair(src, into, true)
+
+			srcScope := mustFetchScopeFile(src)
+			dstScope := mustFetchScopeFile(into)
+			merged, conflicts := mergeScopeFiles(dstScope, srcScope)
+			plan := planScope(dstScope, merged)
+
+			sm := NewScopeManager(into)
+			sm.setRules(merged.InScope, merged.OutScope)
+			copies := planAssetCopies(src, into, sm)
+
+			fmt.Println(title(fmt.Sprintf("Merge %s → %s", src, into)))
+			printScopePlan(plan)
+			if len(conflicts) > 0 {
+				fmt.Println(warning(fmt.Sprintf("⚠️ %d scope conflicts, keeping %s's rules:", len(conflicts), into)))
+				for _, conflict := range conflicts {
+					fmt.Printf("  %s %s\n", warning("!"), conflict)
+				}
+			}
+			printAssetCopies(copies)
+
+			if dryRun {
+				fmt.Println(info("ℹ️  Dry run, nothing merged."))
+				return
+			}
+			if !autoApprove && !confirm(fmt.Sprintf("Merge %s into %s?", src, into)) {
+				fmt.Println(warning("⚠️ Merge cancelled"))
+				return
+			}
+
+			if !plan.Empty() {
+				if err := applyScopePlan(into, plan, "company merge"); err != nil {
+					fmt.Println(errorC("❌ Failed to merge scope: " + err.Error()))
+					os.Exit(1)
+				}
+			}
+			if remaining, err := copyAssets(into, copies, batchSize); err != nil {
+				fmt.Println(errorC("❌ Failed to merge assets: " + err.Error()))
+				exitUnfinished(fmt.Sprintf("The scope of %s was merged into %s, but not all assets", src, into), remaining,
+					"Merging again adds what is missing")
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Merged %s into %s", src, into)))
+		},
+	}
+	mergeCmd.Flags().String("into", "", "Company to merge into")
+	mergeCmd.Flags().Int("batch-size", 500, "Items per add request")
+	addCompanyOpFlags(mergeCmd)
+
+	return mergeCmd
+}
+
+// addCompanyOpFlags adds the preview and confirmation flags
+func addCompanyOpFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Only show what would be done")
+	cmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+}
+
+// mustCompanyPair checks the source and target of a company operation: both
+// named and different, the source existing, and the target existing only
+// when it should
+func mustCompanyPair(src, dst string, dstExists bool) {
+	if src == "" || dst == "" {
+		fmt.Println(errorC("❌ Both a source and a target company are needed"))
+		os.Exit(1)
+	}
+	if src == dst {
+		fmt.Println(errorC("❌ Source and target are the same company"))
+		os.Exit(1)
+	}
+
+	respData, err := apiRequest("GET", companyListEndpoint, nil)
+	if err != nil {
+		fmt.Println(errorC("❌ Failed to list companies: " + err.Error()))
+		os.Exit(1)
+	}
+	var companies []string
+	if err := json.Unmarshal(respData, &companies); err != nil {
+		fmt.Println(errorC("❌ Failed to parse company list: " + err.Error()))
+		os.Exit(1)
+	}
+
+	switch {
+	case !slices.Contains(companies, src):
+		fmt.Println(errorC("❌ No such company: " + src))
+		os.Exit(1)
+	case dstExists && !slices.Contains(companies, dst):
+		fmt.Println(errorC("❌ No such company: " + dst))
+		os.Exit(1)
+	case !dstExists && slices.Contains(companies, dst):
+		fmt.Println(errorC("❌ Company already exists: " + dst))
+		os.Exit(1)
+	}
+}
+
+// mustFetchScopeFile reads a company's scope from the server, exiting if it
+// can't
+func mustFetchScopeFile(company string) *ScopeFile {
+	scope, err := fetchScopeFile(company)
+	if err != nil {
+		fmt.Println(errorC(fmt.Sprintf("❌ Failed to fetch the scope of %s: %s", company, err.Error())))
+		os.Exit(1)
+	}
+	return scope
+}
+
+// mergeScopeFiles returns the union of two scopes. A pattern in scope in one
+// and out of scope in the other keeps its place in dst, and is reported as
+// a conflict.
+func mergeScopeFiles(dst, src *ScopeFile) (*ScopeFile, []string) {
+	merged := &ScopeFile{
+		Company:  dst.Company,
+		InScope:  append([]string(nil), dst.InScope...),
+		OutScope: append([]string(nil), dst.OutScope...),
+	}
+	dstIn := scopePatternSet(dst.InScope)
+	dstOut := scopePatternSet(dst.OutScope)
+	srcIn := scopePatternSet(src.InScope)
+
+	var conflicts []string
+	for _, pattern := range src.InScope {
+		switch key := scopePatternKey(pattern); {
+		case dstOut[key]:
+			conflicts = append(conflicts, fmt.Sprintf("%s is in scope in %s but out of scope in %s", pattern, src.Company, dst.Company))
+		case !dstIn[key]:
+			merged.InScope = append(merged.InScope, pattern)
+			dstIn[key] = true
+		}
+	}
+	for _, pattern := range src.OutScope {
+		switch key := scopePatternKey(pattern); {
+		case dstIn[key] && !srcIn[key]:
+			conflicts = append(conflicts, fmt.Sprintf("%s is out of scope in %s but in scope in %s", pattern, src.Company, dst.Company))
+		case !dstOut[key] && !dstIn[key]:
+			merged.OutScope = append(merged.OutScope, pattern)
+			dstOut[key] = true
+		}
+	}
+	return merged, conflicts
+}
+
+// scopePatternSet returns the comparison keys of patterns
+func scopePatternSet(patterns []string) map[string]bool {
+	set := make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		set[scopePatternKey(pattern)] = true
+	}
+	return set
+}
+
+// planAssetCopies works out which assets of src to add to dst, see
+// planAssetAdds
+func planAssetCopies(src, dst string, sm *ScopeManager) []assetCopy {
+	assets := make(map[string][]string)
+	for _, resource := range companyAssetResources {
+		items, err := fetchResourceList(resource.listEndpoint, src)
+		if err != nil {
+			fmt.Println(errorC(fmt.Sprintf("❌ Failed to list %ss of %s: %s", resource.name, src, err.Error())))
+			os.Exit(1)
+		}
+		assets[resource.dataKey] = items
+	}
+	return planAssetAdds(assets, dst, sm)
+}
+
+// planAssetAdds works out which of the given assets, keyed by data key, to
+// add to dst: those dst doesn't have, and, with a scope manager, that pass
+// its rules. An empty dst is a new company.
+func planAssetAdds(assets map[string][]string, dst string, sm *ScopeManager) []assetCopy {
+	var copies []assetCopy
+	for _, resource := range companyAssetResources {
+		items := assets[resource.dataKey]
+		have := make(map[string]bool)
+		if dst != "" {
+			dstItems, err := fetchResourceList(resource.listEndpoint, dst)
+			if err != nil {
+				fmt.Println(errorC(fmt.Sprintf("❌ Failed to list %ss of %s: %s", resource.name, dst, err.Error())))
+				os.Exit(1)
+			}
+			for _, item := range dstItems {
+				have[assetItemKey(resource.name, item)] = true
+			}
+		}
+
+		plan := assetCopy{name: resource.name, dataKey: resource.dataKey, addEndpoint: resource.addEndpoint}
+		var candidates []string
+		for _, item := range items {
+			key := assetItemKey(resource.name, item)
+			if have[key] {
+				plan.existing++
+				continue
+			}
+			have[key] = true
+			candidates = append(candidates, item)
+		}
+
+		if sm == nil || allowOutOfScope {
+			plan.add = candidates
+		} else {
+			decide := scopeDeciders[resource.name]
+			decisions := evaluateScope(candidates, func(item string) ScopeDecision {
+				return decide(sm, item)
+			})
+			for i, decision := range decisions {
+				if decision.Accepted {
+					plan.add = append(plan.add, candidates[i])
+				} else {
+					plan.rejected = append(plan.rejected, candidates[i])
+					plan.reasons = append(plan.reasons, decision.Detail())
+				}
+			}
+		}
+		copies = append(copies, plan)
+	}
+	return copies
+}
+
+// assetItemKey normalizes a stored item for comparison; domains may be
+// stored as domain:ip, ASNs with or without AS
+func assetItemKey(name, item string) string {
+	switch name {
+	case "domain":
+		return strings.ToLower(strings.Split(item, ":")[0])
+	case "ip":
+		return extractIP(item)
+	}
+	if asn, ok := parseASN(item); ok {
+		return "AS" + strconv.FormatUint(uint64(asn), 10)
+	}
+	return strings.ToUpper(strings.TrimSpace(item))
+}
+
+// printAssetCopies shows what copyAssets would add
+func printAssetCopies(copies []assetCopy) {
+	for _, plan := range copies {
+		line := fmt.Sprintf("  %s %d %ss to add", success("+"), len(plan.add), plan.name)
+		if plan.existing > 0 {
+			line += info(fmt.Sprintf(", %d already there", plan.existing))
+		}
+		if len(plan.rejected) > 0 {
+			line += warning(fmt.Sprintf(", %d out of the merged scope", len(plan.rejected)))
+		}
+		fmt.Println(line)
+		for i, item := range plan.rejected {
+			fmt.Printf("    %s %s - %s\n", warning("!"), domainClr(item), plan.reasons[i])
+		}
+	}
+}
+
+// copyAssets adds the planned assets to a company, bypassing scope filtering
+// since the plan already applied it. It stops at the first failure and
+// returns what was left to add.
+func copyAssets(company string, copies []assetCopy, batchSize int) ([]assetCopy, error) {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for i, plan := range copies {
+		if len(plan.add) == 0 {
+			continue
+		}
+		added, err := postInBatches(plan.addEndpoint, company, plan.dataKey, plan.add, batchSize, "Added "+plan.name+"s")
+		if err != nil {
+			remaining := []assetCopy{{name: plan.name, dataKey: plan.dataKey, addEndpoint: plan.addEndpoint, add: plan.add[added:]}}
+			for _, rest := range copies[i+1:] {
+				remaining = append(remaining, assetCopy{name: rest.name, dataKey: rest.dataKey, addEndpoint: rest.addEndpoint, add: rest.add})
+			}
+			return remaining, fmt.Errorf("added %d %ss before failing: %w", added, plan.name, err)
+		}
+		fmt.Println(success(fmt.Sprintf("✅ Added %d %ss", added, plan.name)))
+	}
+	return nil, nil
+}
+
+// exitUnfinished reports an operation that failed after changing a company:
+// what happened, the assets it didn't get to and how to recover
+func exitUnfinished(what string, remaining []assetCopy, hint string) {
+	fmt.Println(warning("⚠️ " + what))
+	var left []assetCopy
+	for _, plan := range remaining {
+		if len(plan.add) > 0 {
+			left = append(left, assetCopy{name: plan.name, add: plan.add})
+		}
+	}
+	if len(left) > 0 {
+		fmt.Println(info("Not copied:"))
+		printAssetCopies(left)
+	}
+	fmt.Println(info("ℹ️  " + hint))
+	os.Exit(1)
+}
+
+// moveScopeHistory moves the local scope history of a renamed company and
+// drops both cached scopes
+func moveScopeHistory(from, to string) {
+	invalidateScopeCache(from)
+	invalidateScopeCache(to)
+
+	oldPath, newPath := scopeHistoryPath(from), scopeHistoryPath(to)
+	if _, err := os.Stat(oldPath); err != nil {
+		return
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		fmt.Printf("%s Scope history of %s kept at %s, %s already has one\n", warning("⚠️"), from, oldPath, to)
+		return
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		fmt.Printf("%s Scope history not moved: %s\n", warning("⚠️"), err.Error())
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAssetItemKey(t *testing.T) {
+	tests := []struct {
+		name, a, b string
+	}{
+		{"domain", "App.Example.com", "app.example.com:10.0.0.1"},
+		{"ip", "10.0.0.1", "10.0.0.1"},
+		{"asn", "AS13335", "13335"},
+		{"asn", "as13335", " AS13335 "},
+	}
+	for _, tt := range tests {
+		if ka, kb := assetItemKey(tt.name, tt.a), assetItemKey(tt.name, tt.b); ka != kb {
+			t.Errorf("%s %q and %q: keys %q and %q differ", tt.name, tt.a, tt.b, ka, kb)
+		}
+	}
+	if assetItemKey("asn", "AS1") == assetItemKey("asn", "AS13335") {
+		t.Error("different ASNs got the same key")
+	}
+}
+
+func TestMergeScopeFiles(t *testing.T) {
+	dst := &ScopeFile{
+		Company:  "dst",
+		InScope:  []string{"*.example.com", "old.example.org"},
+		OutScope: []string{"*.corp.example.com"},
+	}
+	src := &ScopeFile{
+		Company:  "src",
+		InScope:  []string{"*.Example.com", "*.corp.example.com", "*.src.io"},
+		OutScope: []string{"old.example.org", "*.cdn.src.io", "both.example.net"},
+	}
+
+	merged, conflicts := mergeScopeFiles(dst, src)
+
+	wantIn := []string{"*.example.com", "old.example.org", "*.src.io"}
+	wantOut := []string{"*.corp.example.com", "*.cdn.src.io", "both.example.net"}
+	if !reflect.DeepEqual(merged.InScope, wantIn) {
+		t.Errorf("inscope %v, want %v", merged.InScope, wantIn)
+	}
+	if !reflect.DeepEqual(merged.OutScope, wantOut) {
+		t.Errorf("outscope %v, want %v", merged.OutScope, wantOut)
+	}
+	wantConflicts := []string{
+		"*.corp.example.com is in scope in src but out of scope in dst",
+		"old.example.org is out of scope in src but in scope in dst",
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("conflicts %q, want %q", conflicts, wantConflicts)
+	}
+
+	// dst is not modified
+	if len(dst.InScope) != 2 || len(dst.OutScope) != 1 {
+		t.Errorf("dst changed to %v / %v", dst.InScope, dst.OutScope)
+	}
+}
+
+func TestPlanAssetAdds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stored := map[string][]string{
+			"/api/domains":  {"www.example.com:10.0.0.1"},
+			"/api/ip/list":  {},
+			"/api/asn/list": {"13335"},
+		}
+		json.NewEncoder(w).Encode(stored[r.URL.Path])
+	}))
+	defer server.Close()
+	defer func(old Config) { config = old }(config)
+	config = Config{API: server.URL, Token: "test"}
+
+	assets := map[string][]string{
+		"domains": {"www.example.com", "api.example.com", "other.net", "API.example.com"},
+		"ips":     {"10.0.0.1", "192.0.2.1"},
+		"asns":    {"AS13335", "AS15169"},
+	}
+	sm := NewScopeManager("acme")
+	sm.setRules([]string{"*.example.com", "10.0.0.0/24", "AS15169"}, nil)
+
+	type plan struct {
+		add, rejected []string
+		existing      int
+	}
+	summarize := func(copies []assetCopy) map[string]plan {
+		plans := make(map[string]plan)
+		for _, c := range copies {
+			plans[c.name] = plan{c.add, c.rejected, c.existing}
+		}
+		return plans
+	}
+
+	// Into an existing company: what it has is skipped, the rest is checked
+	want := map[string]plan{
+		"domain": {add: []string{"api.example.com"}, rejected: []string{"other.net"}, existing: 2},
+		"ip":     {add: []string{"10.0.0.1"}, rejected: []string{"192.0.2.1"}},
+		"asn":    {add: []string{"AS15169"}, existing: 1},
+	}
+	if got := summarize(planAssetAdds(assets, "acme", sm)); !reflect.DeepEqual(got, want) {
+		t.Errorf("existing company: got %+v, want %+v", got, want)
+	}
+
+	// A new company without a scope manager gets everything once
+	want = map[string]plan{
+		"domain": {add: []string{"www.example.com", "api.example.com", "other.net"}, existing: 1},
+		"ip":     {add: []string{"10.0.0.1", "192.0.2.1"}},
+		"asn":    {add: []string{"AS13335", "AS15169"}},
+	}
+	if got := summarize(planAssetAdds(assets, "", nil)); !reflect.DeepEqual(got, want) {
+		t.Errorf("new company: got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Finding and note endpoints
+const (
+	findingAddEndpoint    = "/api/findings/add"
+	findingUpdateEndpoint = "/api/findings/update"
+	findingGetEndpoint    = "/api/findings/get"
+	findingListEndpoint   = "/api/findings"
+	noteAddEndpoint       = "/api/notes/add"
+	noteListEndpoint      = "/api/notes"
+)
+
+// Finding is a candidate vulnerability on an asset. Body is markdown.
+type Finding struct {
+	ID          int    `json:"id,omitempty"`
+	Title       string `json:"title"`
+	Severity    string `json:"severity"`
+	Status      string `json:"status"`
+	Asset       string `json:"asset,omitempty"`
+	AssetType   string `json:"asset_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+	CloseReason string `json:"close_reason,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+}
+
+// Severities from most to least severe
+var findingSeverities = []string{"critical", "high", "medium", "low", "info"}
+
+// A finding starts out new, is triaged and then reported. Any open finding
+// can be closed with one of the closed statuses, and a closed finding can
+// be reopened as new or triaged.
+var (
+	findingOpenStatuses   = []string{"new", "triaged", "reported"}
+	findingClosedStatuses = []string{"resolved", "duplicate", "false-positive", "wont-fix"}
+	findingTransitions    = map[string][]string{
+		"new":      {"triaged", "reported"},
+		"triaged":  {"new", "reported"},
+		"reported": {"triaged"},
+	}
+)
+
+var assetDomainRegex = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-z0-9_-]+)+$`)
+
+// createFindingCommand builds the "finding" subsystem
+func createFindingCommand() *cobra.Command {
+	findingCmd := &cobra.Command{
+		Use:   "finding",
+		Short: "🐞 Track candidate vulnerabilities",
+		Long: fmt.Sprintf(`Track candidate vulnerabilities next to the assets they were found on.
+
+Severities: %s
+Status workflow: new → triaged → reported, then closed as one of %s.
+Closed findings can be reopened with update --status new or triaged.`,
+			strings.Join(findingSeverities, ", "), strings.Join(findingClosedStatuses, ", ")),
+		Example: `  # Record a finding with a markdown write-up
+  bbrf company finding add "Open redirect on login" --severity medium \
+    --asset https://app.example.com/login --body-file redirect.md -c acme
+
+  # Open findings, most severe first
+  bbrf company finding list --status open -c acme
+
+  # Move it along and close it
+  bbrf company finding update 12 --status reported -c acme
+  bbrf company finding close 12 --as resolved -c acme`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <title>",
+		Short: "➕ Add a finding",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			severity, _ := cmd.Flags().GetString("severity")
+			asset, _ := cmd.Flags().GetString("asset")
+
+			finding := Finding{Title: strings.TrimSpace(args[0]), Severity: strings.ToLower(severity), Status: "new"}
+			if finding.Title == "" {
+				fmt.Println(errorC("❌ A finding needs a title"))
+				os.Exit(1)
+			}
+			if !slices.Contains(findingSeverities, finding.Severity) {
+				fmt.Println(errorC("❌ Invalid severity: " + severity + " (use " + strings.Join(findingSeverities, ", ") + ")"))
+				os.Exit(1)
+			}
+			if asset != "" {
+				finding.Asset, finding.AssetType = mustAssetRef(asset)
+			}
+			finding.Body = readFindingBody(cmd)
+
+			body := map[string]interface{}{"company": company, "finding": finding}
+			respData, err := apiRequest("POST", findingAddEndpoint, body)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to add finding: " + err.Error()))
+				os.Exit(1)
+			}
+
+			var created Finding
+			if json.Unmarshal(respData, &created) == nil && created.ID != 0 {
+				fmt.Println(success(fmt.Sprintf("✅ Added finding #%d: %s", created.ID, finding.Title)))
+			} else {
+				fmt.Println(success("✅ Added finding: " + finding.Title))
+			}
+		},
+	}
+	addCmd.Flags().StringP("severity", "s", "medium", "Severity: "+strings.Join(findingSeverities, ", "))
+	addCmd.Flags().StringP("asset", "a", "", "Domain, IP or URL the finding is on")
+	addFindingBodyFlags(addCmd)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "📋 List findings",
+		Example: `  bbrf company finding list -c acme
+  bbrf company finding list --status open --severity high,critical -c acme
+  bbrf company finding list --asset app.example.com --output-format csv -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			statuses, _ := cmd.Flags().GetStringSlice("status")
+			severities, _ := cmd.Flags().GetStringSlice("severity")
+			asset, _ := cmd.Flags().GetString("asset")
+			checkOutputFormat()
+
+			query := url.Values{"company": {company}}
+			var expanded []string
+			for _, status := range statuses {
+				switch status = strings.ToLower(status); status {
+				case "open":
+					expanded = append(expanded, findingOpenStatuses...)
+				case "closed":
+					expanded = append(expanded, findingClosedStatuses...)
+				default:
+					if !slices.Contains(findingOpenStatuses, status) && !slices.Contains(findingClosedStatuses, status) {
+						fmt.Println(errorC("❌ Invalid status: " + status))
+						os.Exit(1)
+					}
+					expanded = append(expanded, status)
+				}
+			}
+			if len(expanded) > 0 {
+				query.Set("status", strings.Join(expanded, ","))
+			}
+			if len(severities) > 0 {
+				query.Set("severity", strings.ToLower(strings.Join(severities, ",")))
+			}
+			if asset != "" {
+				// Stored assets are normalized, see assetRef
+				asset, _ = mustAssetRef(asset)
+				query.Set("asset", asset)
+			}
+
+			respData, err := apiRequest("GET", findingListEndpoint+"?"+query.Encode(), nil)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to list findings: " + err.Error()))
+				os.Exit(1)
+			}
+			var findings []Finding
+			if err := json.Unmarshal(respData, &findings); err != nil {
+				fmt.Println(errorC("❌ Failed to parse findings: " + err.Error()))
+				os.Exit(1)
+			}
+			sortFindings(findings)
+			printFindings(findings)
+		},
+	}
+	listCmd.Flags().StringSlice("status", nil, "Only these statuses, or open or closed (comma separated)")
+	listCmd.Flags().StringSlice("severity", nil, "Only these severities (comma separated)")
+	listCmd.Flags().String("asset", "", "Only findings on this asset")
+
+	showCmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "👁️  Show a finding with its write-up",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			finding := mustFetchFinding(args[0])
+			fmt.Println(header(fmt.Sprintf(" 🐞 #%d %s ", finding.ID, finding.Title)))
+			fmt.Printf("%s %s\n", info("Severity:"), severityColor(finding.Severity)(strings.ToUpper(finding.Severity)))
+			fmt.Printf("%s %s\n", info("Status:  "), finding.Status)
+			if finding.CloseReason != "" {
+				fmt.Printf("%s %s\n", info("Reason:  "), finding.CloseReason)
+			}
+			if finding.Asset != "" {
+				fmt.Printf("%s %s %s\n", info("Asset:   "), domainClr(finding.Asset), info("("+finding.AssetType+")"))
+			}
+			if finding.CreatedAt != "" {
+				fmt.Printf("%s %s\n", info("Created: "), formatSeen(finding.CreatedAt))
+			}
+			if finding.UpdatedAt != "" {
+				fmt.Printf("%s %s\n", info("Updated: "), formatSeen(finding.UpdatedAt))
+			}
+			if finding.Body != "" {
+				fmt.Println()
+				fmt.Println(finding.Body)
+			}
+		},
+	}
+
+	updateCmd := &cobra.Command{
+		Use:   "update <id>",
+		Short: "✏️  Update a finding",
+		Long: `Change the title, severity, status, asset or write-up of a finding. Status
+changes must follow the workflow: new → triaged → reported, and closed
+findings can only be reopened as new or triaged.`,
+		Example: `  bbrf company finding update 12 --status triaged --severity high -c acme
+  bbrf company finding update 12 --body-file writeup.md -c acme`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			finding := mustFetchFinding(args[0])
+			changes := make(map[string]interface{})
+
+			if cmd.Flags().Changed("title") {
+				title, _ := cmd.Flags().GetString("title")
+				changes["title"] = strings.TrimSpace(title)
+			}
+			if cmd.Flags().Changed("severity") {
+				severity, _ := cmd.Flags().GetString("severity")
+				if severity = strings.ToLower(severity); !slices.Contains(findingSeverities, severity) {
+					fmt.Println(errorC("❌ Invalid severity: " + severity + " (use " + strings.Join(findingSeverities, ", ") + ")"))
+					os.Exit(1)
+				}
+				changes["severity"] = severity
+			}
+			if cmd.Flags().Changed("status") {
+				status, _ := cmd.Flags().GetString("status")
+				status = strings.ToLower(status)
+				if slices.Contains(findingClosedStatuses, status) {
+					fmt.Println(errorC("❌ Use finding close --as " + status + " to close a finding"))
+					os.Exit(1)
+				}
+				if err := checkFindingTransition(finding.Status, status); err != nil {
+					fmt.Println(errorC("❌ " + err.Error()))
+					os.Exit(1)
+				}
+				changes["status"] = status
+				if slices.Contains(findingClosedStatuses, finding.Status) {
+					changes["close_reason"] = ""
+				}
+			}
+			if cmd.Flags().Changed("asset") {
+				asset, _ := cmd.Flags().GetString("asset")
+				changes["asset"], changes["asset_type"] = "", ""
+				if asset != "" {
+					changes["asset"], changes["asset_type"] = mustAssetRef(asset)
+				}
+			}
+			if cmd.Flags().Changed("body") || cmd.Flags().Changed("body-file") {
+				changes["body"] = readFindingBody(cmd)
+			}
+
+			if len(changes) == 0 {
+				fmt.Println(warning("⚠️ Nothing to update"))
+				return
+			}
+			updateFinding(finding, changes)
+		},
+	}
+	updateCmd.Flags().String("title", "", "New title")
+	updateCmd.Flags().StringP("severity", "s", "", "New severity")
+	updateCmd.Flags().String("status", "", "New status: "+strings.Join(findingOpenStatuses, ", "))
+	updateCmd.Flags().StringP("asset", "a", "", "New asset, empty to unlink")
+	addFindingBodyFlags(updateCmd)
+
+	closeCmd := &cobra.Command{
+		Use:   "close <id>",
+		Short: "✅ Close a finding",
+		Example: `  bbrf company finding close 12 -c acme
+  bbrf company finding close 14 --as duplicate --reason "same as #9" -c acme`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			status, _ := cmd.Flags().GetString("as")
+			reason, _ := cmd.Flags().GetString("reason")
+
+			status = strings.ToLower(status)
+			if !slices.Contains(findingClosedStatuses, status) {
+				fmt.Println(errorC("❌ Invalid closed status: " + status + " (use " + strings.Join(findingClosedStatuses, ", ") + ")"))
+				os.Exit(1)
+			}
+			finding := mustFetchFinding(args[0])
+			if err := checkFindingTransition(finding.Status, status); err != nil {
+				fmt.Println(errorC("❌ " + err.Error()))
+				os.Exit(1)
+			}
+			updateFinding(finding, map[string]interface{}{"status": status, "close_reason": reason})
+		},
+	}
+	closeCmd.Flags().String("as", "resolved", "Closed status: "+strings.Join(findingClosedStatuses, ", "))
+	closeCmd.Flags().String("reason", "", "Why the finding was closed")
+
+	findingCmd.AddCommand(addCmd, listCmd, showCmd, updateCmd, closeCmd)
+	return findingCmd
+}
+
+// createNoteCommand builds "note", free-form triage notes on assets
+func createNoteCommand() *cobra.Command {
+	noteCmd := &cobra.Command{
+		Use:   "note",
+		Short: "🗒️  Keep triage notes on assets",
+		Example: `  # Note something about a domain, IP or URL
+  bbrf company note add app.example.com "Login form has no rate limiting" -c acme
+
+  # Notes from a file or stdin
+  bbrf company note add 10.0.0.5 - -c acme < notes.md
+
+  # Notes on an asset
+  bbrf company note list app.example.com -c acme`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <asset> <text...>",
+		Short: "➕ Add a note to an asset",
+		Long:  `Add a markdown note to a domain, IP or URL. Give the text as arguments, or "-" to read it from stdin.`,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			asset, assetType := mustAssetRef(args[0])
+
+			text := strings.Join(args[1:], " ")
+			if len(args) == 2 && args[1] == "-" {
+				input, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					fmt.Println(errorC("❌ Failed to read stdin: " + err.Error()))
+					os.Exit(1)
+				}
+				text = string(input)
+			}
+			if text = strings.TrimSpace(text); text == "" {
+				fmt.Println(errorC("❌ The note is empty"))
+				os.Exit(1)
+			}
+
+			body := map[string]string{"company": company, "asset": asset, "asset_type": assetType, "text": text}
+			if _, err := apiRequest("POST", noteAddEndpoint, body); err != nil {
+				fmt.Println(errorC("❌ Failed to add note: " + err.Error()))
+				os.Exit(1)
+			}
+			fmt.Println(success("✅ Added note to " + asset))
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list [asset]",
+		Short: "📋 List notes, of one asset or all",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			query := url.Values{"company": {company}}
+			if len(args) == 1 {
+				asset, _ := mustAssetRef(args[0])
+				query.Set("asset", asset)
+			}
+			call("GET", noteListEndpoint+"?"+query.Encode(), "")
+		},
+	}
+
+	noteCmd.AddCommand(addCmd, listCmd)
+	return noteCmd
+}
+
+// addFindingBodyFlags adds the flags giving a finding's markdown write-up
+func addFindingBodyFlags(cmd *cobra.Command) {
+	cmd.Flags().String("body", "", "Markdown write-up")
+	cmd.Flags().String("body-file", "", "Read the markdown write-up from this file, - for stdin")
+}
+
+// readFindingBody returns the write-up given by --body or --body-file
+func readFindingBody(cmd *cobra.Command) string {
+	body, _ := cmd.Flags().GetString("body")
+	bodyFile, _ := cmd.Flags().GetString("body-file")
+	if bodyFile == "" {
+		return body
+	}
+	if body != "" {
+		fmt.Println(errorC("❌ Use either --body or --body-file"))
+		os.Exit(1)
+	}
+
+	var content []byte
+	var err error
+	if bodyFile == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(strings.TrimPrefix(bodyFile, "@"))
+	}
+	if err != nil {
+		fmt.Println(errorC("❌ Failed to read body: " + err.Error()))
+		os.Exit(1)
+	}
+	return string(content)
+}
+
+// assetRef normalises an asset reference and tells whether it is a domain,
+// IP or URL
+func assetRef(asset string) (string, string, bool) {
+	asset = strings.TrimSpace(asset)
+	if strings.Contains(asset, "://") {
+		_, ok := parseURLTarget(asset)
+		return asset, "url", ok
+	}
+	if addr, err := netip.ParseAddr(asset); err == nil {
+		return addr.Unmap().String(), "ip", true
+	}
+	domain := strings.ToLower(strings.TrimSuffix(asset, "."))
+	return domain, "domain", assetDomainRegex.MatchString(domain)
+}
+
+// mustAssetRef is assetRef exiting on assets that are none of the three
+func mustAssetRef(asset string) (string, string) {
+	ref, assetType, ok := assetRef(asset)
+	if !ok {
+		fmt.Println(errorC("❌ Not a domain, IP or URL: " + asset))
+		os.Exit(1)
+	}
+	return ref, assetType
+}
+
+// checkFindingTransition checks a status change against the workflow
+func checkFindingTransition(from, to string) error {
+	switch {
+	case from == to:
+		return fmt.Errorf("the finding is already %s", from)
+	case slices.Contains(findingClosedStatuses, from):
+		if to == "new" || to == "triaged" {
+			return nil
+		}
+		return fmt.Errorf("a %s finding can only be reopened as new or triaged", from)
+	case slices.Contains(findingClosedStatuses, to), slices.Contains(findingTransitions[from], to):
+		return nil
+	case !slices.Contains(findingOpenStatuses, to):
+		return fmt.Errorf("invalid status: %s (use %s)", to, strings.Join(findingOpenStatuses, ", "))
+	}
+	return fmt.Errorf("can't go from %s to %s (%s can become %s, or be closed)",
+		from, to, from, strings.Join(findingTransitions[from], " or "))
+}
+
+// mustFetchFinding fetches a finding by id, exiting if it can't
+func mustFetchFinding(id string) Finding {
+	if _, err := strconv.Atoi(strings.TrimPrefix(id, "#")); err != nil {
+		fmt.Println(errorC("❌ Invalid finding id: " + id))
+		os.Exit(1)
+	}
+	query := url.Values{"company": {company}, "id": {strings.TrimPrefix(id, "#")}}
+	respData, err := apiRequest("GET", findingGetEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		fmt.Println(errorC("❌ Failed to fetch finding: " + err.Error()))
+		os.Exit(1)
+	}
+	var finding Finding
+	if err := json.Unmarshal(respData, &finding); err != nil {
+		fmt.Println(errorC("❌ Failed to parse finding: " + err.Error()))
+		os.Exit(1)
+	}
+	return finding
+}
+
+// updateFinding posts changes to a finding
+func updateFinding(finding Finding, changes map[string]interface{}) {
+	body := map[string]interface{}{"company": company, "id": finding.ID, "changes": changes}
+	if _, err := apiRequest("POST", findingUpdateEndpoint, body); err != nil {
+		fmt.Println(errorC("❌ Failed to update finding: " + err.Error()))
+		os.Exit(1)
+	}
+	if status, ok := changes["status"].(string); ok && status != finding.Status {
+		fmt.Println(success(fmt.Sprintf("✅ Finding #%d: %s → %s", finding.ID, finding.Status, status)))
+	} else {
+		fmt.Println(success(fmt.Sprintf("✅ Updated finding #%d", finding.ID)))
+	}
+}
+
+// sortFindings orders findings most severe first, then by id
+func sortFindings(findings []Finding) {
+	rank := func(severity string) int {
+		for i, s := range findingSeverities {
+			if s == severity {
+				return i
+			}
+		}
+		return len(findingSeverities)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if rank(findings[i].Severity) != rank(findings[j].Severity) {
+			return rank(findings[i].Severity) < rank(findings[j].Severity)
+		}
+		return findings[i].ID < findings[j].ID
+	})
+}
+
+// printFindings renders findings in the chosen output format
+func printFindings(findings []Finding) {
+	switch outputFormat {
+	case "json":
+		out, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(out))
+		return
+	case "csv":
+		rows := make([][]string, len(findings))
+		for i, f := range findings {
+			rows[i] = []string{strconv.Itoa(f.ID), f.Severity, f.Status, f.Title, f.Asset, f.AssetType, f.CreatedAt, f.UpdatedAt}
+		}
+		writeCSV([]string{"id", "severity", "status", "title", "asset", "asset_type", "created_at", "updated_at"}, rows)
+		return
+	}
+
+	fmt.Println(header(" 🐞 Findings "))
+	for _, f := range findings {
+		line := fmt.Sprintf("%s %s %s %s",
+			warning(fmt.Sprintf("#%-4d", f.ID)),
+			severityColor(f.Severity)(fmt.Sprintf("%-8s", strings.ToUpper(f.Severity))),
+			info(fmt.Sprintf("%-14s", f.Status)),
+			f.Title)
+		if f.Asset != "" {
+			line += " " + domainClr("— "+f.Asset)
+		}
+		fmt.Println(line)
+	}
+	fmt.Println(count(fmt.Sprintf("\n📊 Total: %d findings", len(findings))))
+}
+
+// severityColor picks the colour of a severity
+func severityColor(severity string) func(a ...interface{}) string {
+	switch severity {
+	case "critical", "high":
+		return errorC
+	case "medium":
+		return warning
+	case "low":
+		return info
+	}
+	return data
+}
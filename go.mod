@@ -8,6 +8,8 @@ require (
 	github.com/charmbracelet/fang v0.1.0
 	github.com/fatih/color v1.18.0
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/net v0.38.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// scopeDeciders evaluates a stored item of each scope-filtered resource
+var scopeDeciders = map[string]func(*ScopeManager, string) ScopeDecision{
+	"domain": func(sm *ScopeManager, item string) ScopeDecision {
+		// Extract domain from domain:ip format if present
+		return sm.ShouldAcceptDomain(strings.Split(item, ":")[0])
+	},
+	"ip": func(sm *ScopeManager, item string) ScopeDecision {
+		return sm.ShouldAcceptIP(extractIP(item))
+	},
+	"asn": func(sm *ScopeManager, item string) ScopeDecision {
+		return sm.ShouldAcceptASN(item)
+	},
+}
+
+// createPruneCommand builds "<resource> prune --out-of-scope", which removes
+// stored items that no longer pass the current scope rules
+func createPruneCommand(name, dataKey, listEndpoint, removeEndpoint string,
+	decide func(*ScopeManager, string) ScopeDecision) *cobra.Command {
+	plural := name + "s"
+	pruneCmd := &cobra.Command{
+		Use:   "prune --out-of-scope",
+		Short: fmt.Sprintf("✂️  Remove stored %s that are out of scope", plural),
+		Long: fmt.Sprintf(`Evaluate every stored %s against the current scope rules and remove the
+ones that are rejected. Scope filtering only applies when items are added, so
+this catches items that fell out of scope after a scope change. Items that
+aren't valid for the resource can't be checked and are listed but kept.
+
+Runs as a dry run by default; pass --dry-run=false to remove, in batches,
+after confirmation.`, name),
+		Example: fmt.Sprintf(`  # Show what would be removed
+  bbrf company %s prune --out-of-scope -c acme
+
+  # Remove after confirmation
+  bbrf company %s prune --out-of-scope --dry-run=false -c acme
+
+  # Remove without asking, 1000 per request
+  bbrf company %s prune --out-of-scope --dry-run=false -y --batch-size 1000 -c acme`, name, name, name),
+		Run: func(cmd *cobra.Command, args []string) {
+			outOfScope, _ := cmd.Flags().GetBool("out-of-scope")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			autoApprove, _ := cmd.Flags().GetBool("yes")
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+			if !outOfScope {
+				fmt.Println(errorC("❌ Nothing to prune: pass --out-of-scope"))
+				os.Exit(1)
+			}
+			if batchSize < 1 {
+				batchSize = 1
+			}
+
+			fmt.Println(info(fmt.Sprintf("✂️  Checking stored %s of %s against scope...", plural, company)))
+
+			scopeManager := NewScopeManager(company)
+			if err := scopeManager.LoadScope(); err != nil {
+				fmt.Println(errorC("❌ Failed to load scope rules: " + err.Error()))
+				os.Exit(1)
+			}
+
+			items, err := fetchResourceList(listEndpoint, company)
+			if err != nil {
+				fmt.Println(errorC(fmt.Sprintf("❌ Failed to list %s: %s", plural, err.Error())))
+				os.Exit(1)
+			}
+
+			decisions := evaluateScope(items, func(item string) ScopeDecision {
+				return decide(scopeManager, item)
+			})
+
+			removeIdx, invalidIdx := planPrune(decisions)
+			var remove []string
+			for _, i := range removeIdx {
+				remove = append(remove, items[i])
+				fmt.Printf("  %s %s - %s\n", errorC("-"), domainClr(items[i]), warning(decisions[i].Detail()))
+			}
+
+			if len(invalidIdx) > 0 {
+				fmt.Println(warning(fmt.Sprintf("\n⚠️ %d stored %s can't be checked against scope and are kept:", len(invalidIdx), plural)))
+				for _, i := range invalidIdx {
+					fmt.Printf("  %s %s - %s\n", warning("?"), domainClr(items[i]), warning(decisions[i].Detail()))
+				}
+			}
+
+			fmt.Println(count(fmt.Sprintf("\n📊 %d of %d %s are out of scope", len(remove), len(items), plural)))
+			if len(remove) == 0 {
+				return
+			}
+			if dryRun {
+				fmt.Println(info("ℹ️  Dry run, nothing removed. Pass --dry-run=false to remove them."))
+				return
+			}
+
+			if !autoApprove && !confirm(fmt.Sprintf("Remove %d %s?", len(remove), plural)) {
+				fmt.Println(warning("⚠️ Prune cancelled"))
+				return
+			}
+
+			removed, err := postInBatches(removeEndpoint, company, dataKey, remove, batchSize, "Removed")
+			if err != nil {
+				fmt.Println(errorC(fmt.Sprintf("❌ Removed %d %s before failing: %s", removed, plural, err.Error())))
+				os.Exit(1)
+			}
+			fmt.Println(success(fmt.Sprintf("✅ Removed %d %s", removed, plural)))
+		},
+	}
+	pruneCmd.Flags().Bool("out-of-scope", false, fmt.Sprintf("Remove %s rejected by the scope rules", plural))
+	pruneCmd.Flags().Bool("dry-run", true, "Only show what would be removed")
+	pruneCmd.Flags().BoolP("yes", "y", false, "Remove without asking for confirmation")
+	pruneCmd.Flags().Int("batch-size", 500, "Items per remove request")
+
+	return pruneCmd
+}
+
+// planPrune splits scope decisions into the indexes of items to remove,
+// those excluded, negated or matched by no in-scope rule, and of items that
+// aren't valid for their resource, which are reported but never removed
+func planPrune(decisions []ScopeDecision) (remove, invalid []int) {
+	for i, decision := range decisions {
+		switch decision.Precedence {
+		case RuleOutOfScope, RuleNegated, RuleNoMatch:
+			remove = append(remove, i)
+		case RuleInvalid:
+			invalid = append(invalid, i)
+		}
+	}
+	return remove, invalid
+}
+
+// fetchResourceList reads all items of a resource list endpoint, which
+// answers with a JSON array of strings
+func fetchResourceList(endpoint, company string) ([]string, error) {
+	respData, err := apiRequest("GET", endpoint+"?company="+url.QueryEscape(company), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []string
+	if err := json.Unmarshal(respData, &items); err != nil {
+		return nil, fmt.Errorf("unexpected list response: %w", err)
+	}
+	return items, nil
+}
+
+// postInBatches posts items to an add or remove endpoint batchSize at a
+// time, reporting progress as "<progress> n/total", and returns how many were
+// posted
+func postInBatches(endpoint, company, dataKey string, items []string, batchSize int, progress string) (int, error) {
+	posted := 0
+	for start := 0; start < len(items); start += batchSize {
+		batch := items[start:min(start+batchSize, len(items))]
+		body := map[string]string{"company": company, dataKey: strings.Join(batch, " ")}
+		if _, err := apiRequest("POST", endpoint, body); err != nil {
+			return posted, err
+		}
+		posted += len(batch)
+		if len(items) > batchSize {
+			fmt.Printf("%s %s %d/%d\n", info("📦"), progress, posted, len(items))
+		}
+	}
+	return posted, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPlanPrune(t *testing.T) {
+	sm := NewScopeManager("test")
+	sm.setRules(
+		[]string{"*.example.com", "!staging.example.com", "10.0.0.0/24", "AS13335"},
+		[]string{"admin.example.com", "10.0.0.9"},
+	)
+
+	tests := []struct {
+		resource                string
+		items                   []string
+		wantRemove, wantInvalid []int
+	}{
+		{
+			resource:   "domain",
+			items:      []string{"www.example.com", "admin.example.com", "staging.example.com", "other.net", "api.example.com:10.0.0.1"},
+			wantRemove: []int{1, 2, 3},
+		},
+		{
+			resource:    "ip",
+			items:       []string{"10.0.0.1", "10.0.0.9", "192.0.2.1", "not-an-ip"},
+			wantRemove:  []int{1, 2},
+			wantInvalid: []int{3},
+		},
+		{
+			resource:    "asn",
+			items:       []string{"AS13335", "AS15169", "bogus"},
+			wantRemove:  []int{1},
+			wantInvalid: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		decide := scopeDeciders[tt.resource]
+		decisions := evaluateScope(tt.items, func(item string) ScopeDecision { return decide(sm, item) })
+		remove, invalid := planPrune(decisions)
+		if !reflect.DeepEqual(remove, tt.wantRemove) || !reflect.DeepEqual(invalid, tt.wantInvalid) {
+			t.Errorf("%s: remove %v, invalid %v, want %v, %v", tt.resource, remove, invalid, tt.wantRemove, tt.wantInvalid)
+		}
+	}
+
+	// Everything is kept when the scope accepts anything not excluded
+	sm.setRules(nil, nil)
+	decisions := evaluateScope([]string{"www.example.com"}, func(item string) ScopeDecision {
+		return scopeDeciders["domain"](sm, item)
+	})
+	if remove, invalid := planPrune(decisions); remove != nil || invalid != nil {
+		t.Errorf("default accept: remove %v, invalid %v, want none", remove, invalid)
+	}
+}
+
+func TestFetchResourceList(t *testing.T) {
+	var query string
+	body := `["a.example.com","b.example.com"]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	defer func(old Config) { config = old }(config)
+	config = Config{API: server.URL, Token: "test"}
+
+	items, err := fetchResourceList("/api/domains", "acme & co")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []string{"a.example.com", "b.example.com"}) {
+		t.Errorf("items %v", items)
+	}
+	if query != "company=acme+%26+co" {
+		t.Errorf("query %q, want the company escaped", query)
+	}
+
+	// Anything but a JSON list of strings is an error, not a list of lines
+	for _, body = range []string{"a.example.com\nb.example.com\n", `{"error":"busy"}`, `[1,2]`} {
+		if items, err := fetchResourceList("/api/domains", "acme"); err == nil {
+			t.Errorf("body %q: got %v, want an error", body, items)
+		}
+	}
+}
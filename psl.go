@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// The Public Suffix List is compiled into the binary by
+// golang.org/x/net/publicsuffix; bump golang.org/x/net to refresh it. Both its
+// ICANN and private sections count, so github.io is a public suffix just
+// like co.uk.
+
+// isPublicSuffix reports whether a host is itself a listed public suffix,
+// like com, co.uk or github.io. Names the list doesn't have, like localhost
+// or an internal corp TLD, only get a suffix from its default rule and don't
+// count.
+func isPublicSuffix(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	if host == "" {
+		return false
+	}
+	suffix, icann := publicsuffix.PublicSuffix(host)
+	// Private entries all have a dot; the default rule's suffix never does
+	listed := icann || strings.Contains(suffix, ".")
+	return listed && suffix == host
+}
+
+// registrableDomain returns the public suffix of a domain plus one label,
+// e.g. example.co.uk for api.dev.example.co.uk. It fails for public
+// suffixes themselves and for IP addresses.
+func registrableDomain(domain string) (string, bool) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if domain == "" || isIPRule(domain) {
+		return "", false
+	}
+	root, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return "", false
+	}
+	return root, true
+}
+
+// publicSuffixPattern reports whether a host pattern would match every
+// registrable domain under a public suffix, like co.uk, *.com or =*.github.io.
+// An exact =co.uk only matches co.uk itself and is not one. URL rules are
+// judged by their host.
+func publicSuffixPattern(pattern string) bool {
+	if isURLRule(pattern) {
+		rule, err := parseURLRule(pattern)
+		return err == nil && rule.hostPattern != "" && publicSuffixPattern(rule.hostPattern)
+	}
+	host, exact := strings.CutPrefix(strings.ToLower(strings.TrimSpace(pattern)), "=")
+	if exact && !strings.Contains(host, "*") {
+		return false
+	}
+	host = strings.TrimPrefix(host, "*.")
+	if host == "" || strings.Contains(host, "*") || isIPRule(host) || isASNRule(host) {
+		return false
+	}
+	return isPublicSuffix(host)
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestPublicSuffixPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"com", true},
+		{"*.com", true},
+		{"co.uk", true},
+		{"*.co.uk", true},
+		{"github.io", true},
+		{"=*.github.io", true},
+		{"=co.uk", false},
+		{"example.com", false},
+		{"*.example.co.uk", false},
+		{"localhost", false},
+		{"intranet", false},
+		{"*.corp", false},
+		{"10.0.0.0/8", false},
+		{"AS13335", false},
+	}
+	for _, tt := range tests {
+		if got := publicSuffixPattern(tt.pattern); got != tt.want {
+			t.Errorf("publicSuffixPattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Output formats of call, chosen with --output-format
+var outputFormats = []string{"table", "json", "csv"}
+
+// recordKeyColumns name the item in a record, the first present is shown
+// first; recordSeenColumns follow it
+var (
+	recordKeyColumns  = []string{"domain", "ip", "asn", "url", "host", "company", "name", "value"}
+	recordSeenColumns = []string{"first_seen", "last_seen", "source"}
+)
+
+// checkOutputFormat exits if --output-format isn't a known format
+func checkOutputFormat() {
+	for _, known := range outputFormats {
+		if outputFormat == known {
+			return
+		}
+	}
+	fmt.Println(errorC("❌ Unknown output format: " + outputFormat + " (use " + strings.Join(outputFormats, ", ") + ")"))
+	os.Exit(1)
+}
+
+// renderJSON prints a JSON response indented, or as is if it isn't JSON
+func renderJSON(respData []byte) {
+	var jsonData interface{}
+	if err := json.Unmarshal(respData, &jsonData); err != nil {
+		fmt.Println(string(respData))
+		return
+	}
+	out, _ := json.MarshalIndent(jsonData, "", "  ")
+	fmt.Println(string(out))
+}
+
+// renderCSV prints a JSON list or object as CSV with a header row. Lists of
+// plain values get a single value column; anything else is printed as is.
+func renderCSV(respData []byte) {
+	var jsonData interface{}
+	if err := json.Unmarshal(respData, &jsonData); err != nil {
+		fmt.Println(string(respData))
+		return
+	}
+
+	var items []interface{}
+	switch v := jsonData.(type) {
+	case []interface{}:
+		items = v
+	case map[string]interface{}:
+		items = []interface{}{v}
+	default:
+		fmt.Println(formatCell(v))
+		return
+	}
+
+	writeCSV(recordRows(items))
+}
+
+// writeCSV prints rows as CSV under a header row
+func writeCSV(columns []string, rows [][]string) {
+	writer := csv.NewWriter(os.Stdout)
+	writer.Write(columns)
+	writer.WriteAll(rows)
+}
+
+// renderRows prints rows built by the client in the chosen output format
+func renderRows(columns []string, rows [][]string) {
+	switch outputFormat {
+	case "json":
+		records := make([]map[string]string, len(rows))
+		for i, row := range rows {
+			records[i] = make(map[string]string, len(columns))
+			for j, column := range columns {
+				records[i][column] = row[j]
+			}
+		}
+		out, _ := json.MarshalIndent(records, "", "  ")
+		fmt.Println(string(out))
+	case "csv":
+		writeCSV(columns, rows)
+	default:
+		printTable(" 📋 Results ", "items", columns, rows)
+	}
+}
+
+// renderRecordTable prints a list of records as an aligned table, with
+// timestamps in local time
+func renderRecordTable(items []interface{}) {
+	columns, rows := recordRows(items)
+	for _, row := range rows {
+		for i, column := range columns {
+			if column == "first_seen" || column == "last_seen" {
+				row[i] = formatSeen(row[i])
+			}
+		}
+	}
+	printTable(" 📋 Results ", "items", columns, rows)
+}
+
+// printTable prints rows as an aligned, numbered table under a heading, the
+// first column highlighted, and the total as a number of noun
+func printTable(heading, noun string, columns []string, rows [][]string) {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = len(column)
+		for _, row := range rows {
+			widths[i] = max(widths[i], len(row[i]))
+		}
+	}
+	pad := func(value string, i int) string {
+		if i == len(columns)-1 {
+			return value
+		}
+		return fmt.Sprintf("%-*s", widths[i], value)
+	}
+
+	fmt.Println(header(heading))
+	numberWidth := len(fmt.Sprintf("%d.", len(rows)))
+	headings := make([]string, len(columns))
+	for i, column := range columns {
+		headings[i] = pad(strings.ToUpper(column), i)
+	}
+	fmt.Printf("%*s %s\n", numberWidth, "", title(strings.Join(headings, "  ")))
+	for n, row := range rows {
+		cells := make([]string, len(columns))
+		for i, value := range row {
+			if i == 0 {
+				cells[i] = domainClr(pad(value, i))
+			} else {
+				cells[i] = data(pad(value, i))
+			}
+		}
+		fmt.Printf("%s %s\n", warning(fmt.Sprintf("%-*s", numberWidth, fmt.Sprintf("%d.", n+1))), strings.Join(cells, "  "))
+	}
+	fmt.Println(count(fmt.Sprintf("\n📊 Total: %d %s", len(rows), noun)))
+}
+
+// isRecordList reports whether a JSON list holds objects rather than plain
+// values
+func isRecordList(items []interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+	_, ok := items[0].(map[string]interface{})
+	return ok
+}
+
+// recordRows lays out JSON values as rows under the union of their keys:
+// the item key first, then the seen columns, then the rest alphabetically
+func recordRows(items []interface{}) ([]string, [][]string) {
+	if !isRecordList(items) {
+		rows := make([][]string, len(items))
+		for i, item := range items {
+			rows[i] = []string{formatCell(item)}
+		}
+		return []string{"value"}, rows
+	}
+
+	keys := make(map[string]bool)
+	for _, item := range items {
+		if record, ok := item.(map[string]interface{}); ok {
+			for key := range record {
+				keys[key] = true
+			}
+		}
+	}
+
+	var columns []string
+	for _, key := range recordKeyColumns {
+		if keys[key] {
+			columns = append(columns, key)
+			delete(keys, key)
+			break
+		}
+	}
+	for _, key := range recordSeenColumns {
+		if keys[key] {
+			columns = append(columns, key)
+			delete(keys, key)
+		}
+	}
+	var rest []string
+	for key := range keys {
+		rest = append(rest, key)
+	}
+	sort.Strings(rest)
+	columns = append(columns, rest...)
+
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		record, _ := item.(map[string]interface{})
+		rows[i] = make([]string, len(columns))
+		for j, column := range columns {
+			rows[i][j] = formatCell(record[column])
+		}
+	}
+	return columns, rows
+}
+
+// formatCell formats a JSON value for a table or CSV cell
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%v", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, part := range v {
+			parts[i] = formatCell(part)
+		}
+		return strings.Join(parts, ",")
+	default:
+		out, _ := json.Marshal(v)
+		return string(out)
+	}
+}
+
+// formatSeen shows an RFC 3339 timestamp in local time with its age
+func formatSeen(value string) string {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return at.Local().Format("2006-01-02 15:04") + " (" + formatAge(time.Since(at)) + ")"
+}
+
+// formatAge rounds a duration to its largest unit, like 3d ago
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// createDomainRootsCommand builds "domain roots", which lists the unique
+// registrable domains of the stored domains
+func createDomainRootsCommand(listEndpoint string) *cobra.Command {
+	rootsCmd := &cobra.Command{
+		Use:   "roots",
+		Short: "🌳 List the unique registrable domains of stored domains",
+		Long: `Group the stored domains by registrable domain, the public suffix plus one
+label according to the Public Suffix List: api.dev.example.co.uk belongs to
+example.co.uk. IP addresses and bare public suffixes are skipped.`,
+		Example: `  # List the root domains
+  bbrf company domain roots -c acme
+
+  # With the number of stored domains under each, largest first
+  bbrf company domain roots --count -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			withCount, _ := cmd.Flags().GetBool("count")
+
+			domains, err := fetchResourceList(listEndpoint, company)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to list domains: " + err.Error()))
+				os.Exit(1)
+			}
+
+			counts := make(map[string]int)
+			for _, domain := range domains {
+				// Extract domain from domain:ip format if present
+				if root, ok := registrableDomain(strings.Split(domain, ":")[0]); ok {
+					counts[root]++
+				}
+			}
+
+			roots := make([]string, 0, len(counts))
+			for root := range counts {
+				roots = append(roots, root)
+			}
+			sort.Strings(roots)
+
+			if !withCount {
+				for _, root := range roots {
+					fmt.Println(root)
+				}
+				return
+			}
+
+			sort.SliceStable(roots, func(i, j int) bool { return counts[roots[i]] > counts[roots[j]] })
+			for _, root := range roots {
+				fmt.Printf("%7d %s\n", counts[root], root)
+			}
+		},
+	}
+	rootsCmd.Flags().Bool("count", false, "Show how many stored domains are under each root")
+
+	return rootsCmd
+}
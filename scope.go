@@ -0,0 +1,730 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ScopeManager struct {
+	InScope  []string
+	OutScope []string
+	company  string
+
+	// asnPrefixes maps scope ASNs to the prefixes they announce, loaded
+	// from the local prefix-to-ASN dataset
+	asnPrefixes map[uint32][]ipRange
+
+	// Compiled forms of InScope and OutScope, see Compile
+	in          *scopeMatcher
+	out         *scopeMatcher
+	compileOnce sync.Once
+}
+
+// Scope Manager Methods
+func NewScopeManager(company string) *ScopeManager {
+	return &ScopeManager{
+		InScope:  make([]string, 0),
+		OutScope: make([]string, 0),
+		company:  company,
+	}
+}
+
+// LoadScope loads scope rules, from the local cache while it is fresher than
+// the cache TTL and from the server otherwise. An error means the rules could
+// not be fetched or parsed; a company without rules loads successfully with
+// empty lists.
+func (sm *ScopeManager) LoadScope() error {
+	cache, _ := readScopeCache(sm.company)
+	if cache != nil && time.Since(cache.FetchedAt) < scopeCacheTTL() {
+		sm.setRules(cache.InScope, cache.OutScope)
+		return nil
+	}
+	return sm.fetchScope(cache, scopeSourceServer)
+}
+
+// RefreshScope loads scope rules from the server, ignoring the cache, and
+// caches them
+func (sm *ScopeManager) RefreshScope() error {
+	return sm.fetchScope(nil, scopeSourceServer)
+}
+
+// LoadCachedScope loads scope rules from the local cache only, however old
+func (sm *ScopeManager) LoadCachedScope() error {
+	cache, err := readScopeCache(sm.company)
+	if err != nil {
+		return fmt.Errorf("no cached scope for %s, run 'bbrf company scope refresh -c %s' first", sm.company, sm.company)
+	}
+	sm.setRules(cache.InScope, cache.OutScope)
+	return nil
+}
+
+// fetchScope loads both lists from the server, caches them and records them in
+// the scope history under source. Lists of a previous cache entry are
+// revalidated with their ETags.
+func (sm *ScopeManager) fetchScope(cache *scopeCache, source string) error {
+	var inETag, outETag string
+	if cache != nil {
+		inETag, outETag = cache.InETag, cache.OutETag
+	}
+
+	// Load in-scope patterns
+	inscope, err := sm.fetchScopeList("in", inETag)
+	if err != nil {
+		return fmt.Errorf("could not load in-scope rules: %w", err)
+	}
+	if inscope.NotModified {
+		inscope = scopeList{Patterns: cache.InScope, ETag: cache.InETag}
+	}
+
+	// Load out-scope patterns
+	outscope, err := sm.fetchScopeList("out", outETag)
+	if err != nil {
+		return fmt.Errorf("could not load out-of-scope rules: %w", err)
+	}
+	if outscope.NotModified {
+		outscope = scopeList{Patterns: cache.OutScope, ETag: cache.OutETag}
+	}
+
+	fresh := &scopeCache{
+		Company:   sm.company,
+		API:       config.API,
+		FetchedAt: time.Now(),
+		InScope:   inscope.Patterns,
+		OutScope:  outscope.Patterns,
+		InETag:    inscope.ETag,
+		OutETag:   outscope.ETag,
+	}
+	if err := writeScopeCache(fresh); err != nil && verboseScope {
+		fmt.Printf("%s Scope rules not cached: %s\n", warning("⚠️"), err.Error())
+	}
+	recordScopeSnapshot(sm.company, fresh.InScope, fresh.OutScope, source)
+
+	sm.setRules(fresh.InScope, fresh.OutScope)
+	return nil
+}
+
+// setRules replaces the scope lists and compiles them
+func (sm *ScopeManager) setRules(inscope, outscope []string) {
+	sm.InScope = inscope
+	sm.OutScope = outscope
+
+	// Load the prefixes announced by scope ASNs, if a dataset is available
+	if countASNRules(sm.InScope)+countASNRules(sm.OutScope) > 0 {
+		if err := sm.LoadASNDataset(resolveASNDatasetPath()); err != nil && verboseScope {
+			fmt.Printf("%s ASN dataset not loaded, ASN rules will not apply to IPs: %s\n", warning("⚠️"), err.Error())
+		}
+	}
+
+	sm.Compile()
+}
+
+// LoadASNDataset reads a local prefix-to-ASN dataset and keeps the prefixes
+// announced by ASNs referenced in the scope rules. Two line formats are
+// understood, optionally gzip compressed:
+//
+//	1.0.0.0/24	13335                         (pyasn)
+//	1.0.0.0	1.0.0.255	13335	US	CLOUDFLARENET (iptoasn.com)
+func (sm *ScopeManager) LoadASNDataset(path string) error {
+	wanted := make(map[uint32]bool)
+	for _, pattern := range append(append([]string{}, sm.InScope...), sm.OutScope...) {
+		if asn, ok := parseASNRule(pattern); ok {
+			wanted[asn] = true
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	prefixes := make(map[uint32][]ipRange)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+
+		var r ipRange
+		var asnField string
+		if strings.Contains(fields[0], "/") {
+			prefix, err := netip.ParsePrefix(fields[0])
+			if err != nil {
+				continue
+			}
+			prefix = prefix.Masked()
+			r = ipRange{from: prefix.Addr(), to: lastAddrInPrefix(prefix)}
+			asnField = fields[1]
+		} else {
+			if len(fields) < 3 {
+				continue
+			}
+			from, err1 := netip.ParseAddr(fields[0])
+			to, err2 := netip.ParseAddr(fields[1])
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			r = ipRange{from: from, to: to}
+			asnField = fields[2]
+		}
+
+		asn, ok := parseASN(asnField)
+		if !ok || !wanted[asn] {
+			continue
+		}
+		prefixes[asn] = append(prefixes[asn], r)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	sm.asnPrefixes = prefixes
+	sm.Compile()
+	return nil
+}
+
+func resolveASNDatasetPath() string {
+	if asnDatasetPath != "" {
+		return asnDatasetPath
+	}
+	return filepath.Join(filepath.Dir(configPath), "ip2asn.tsv")
+}
+
+func (sm *ScopeManager) fetchScopeFromServer(scopeType string) ([]string, error) {
+	list, err := sm.fetchScopeList(scopeType, "")
+	return list.Patterns, err
+}
+
+// scopeList is one scope list as returned by the server
+type scopeList struct {
+	Patterns    []string
+	ETag        string
+	NotModified bool
+}
+
+// fetchScopeList fetches one scope list. With an etag the request is
+// conditional, and a 304 response is returned with NotModified set.
+func (sm *ScopeManager) fetchScopeList(scopeType, etag string) (scopeList, error) {
+	url := fmt.Sprintf("%s/api/scope/show?company=%s&type=%s", config.API, sm.company, scopeType)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return scopeList{}, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := insecureClient.Do(req)
+	if err != nil {
+		return scopeList{}, err
+	}
+	defer resp.Body.Close()
+
+	respData, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return scopeList{ETag: etag, NotModified: true}, nil
+	}
+	if resp.StatusCode != 200 {
+		return scopeList{}, fmt.Errorf("server returned %s", resp.Status)
+	}
+	list := scopeList{ETag: resp.Header.Get("ETag")}
+
+	// Handle empty response
+	if len(strings.TrimSpace(string(respData))) == 0 {
+		list.Patterns = []string{}
+		return list, nil
+	}
+
+	var patterns []string
+
+	// Try to parse as JSON first
+	err = json.Unmarshal(respData, &patterns)
+	if err != nil {
+		// A JSON body that isn't a list of patterns is an error, not a pattern
+		if looksLikeJSON(respData) {
+			return scopeList{}, fmt.Errorf("unexpected scope response: %w", err)
+		}
+
+		// If JSON parsing fails, treat as plain text (split by lines)
+		text := strings.TrimSpace(string(respData))
+		if text != "" {
+			// Split by newlines and filter empty lines
+			lines := strings.Split(text, "\n")
+			for _, line := range lines {
+				line = strings.TrimSpace(line)
+				if line != "" {
+					patterns = append(patterns, line)
+				}
+			}
+		}
+	}
+
+	list.Patterns = patterns
+	return list, nil
+}
+
+// ShouldAcceptDomain determines if a domain should be accepted based on scope
+// rules. Rules are applied in this order, the first one that applies wins:
+//
+//  1. a match in the out-of-scope list rejects
+//  2. a match on a !negated pattern in the in-scope list rejects
+//  3. a match on any other in-scope pattern accepts
+//  4. if the in-scope list has no positive domain patterns, accept by default
+//  5. otherwise reject
+//
+// Within a list, exact (=host), plain host, *. wildcard, inner wildcard and
+// re: regex patterns all have the same weight. The host of an in-scope URL
+// rule matches after those, since part of it is in scope; out-of-scope URL
+// rules only apply to URLs. !patterns in the out-of-scope list have no
+// effect. In-scope patterns covering a whole public suffix, like co.uk or
+// *.com, never match but still count for step 4. The returned decision
+// records the step that applied as its Precedence, and the pattern that
+// matched.
+func (sm *ScopeManager) ShouldAcceptDomain(domain string) ScopeDecision {
+	item := domain
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	in, out := sm.matchers()
+
+	// First check if it's explicitly out of scope
+	if match, ok := out.matchDomain(domain); ok {
+		return newScopeDecision(item, RuleOutOfScope, "domain matches out-of-scope pattern").matched("out", match)
+	}
+
+	// Then check if the in-scope list excludes it
+	if in.negations != nil {
+		if match, ok := in.negations.matchDomain(domain); ok {
+			match.Pattern = "!" + match.Pattern
+			return newScopeDecision(item, RuleNegated, "domain matches negated in-scope pattern").matched("in", match)
+		}
+	}
+
+	// Then check if it's in scope
+	if match, ok := in.matchDomain(domain); ok {
+		return newScopeDecision(item, RuleInScope, "domain matches in-scope pattern").matched("in", match)
+	}
+	if match, ok := in.matchURLHost(domain); ok {
+		return newScopeDecision(item, RuleInScope, "domain is the host of in-scope URL rule").matched("in", match)
+	}
+
+	// If no in-scope domain patterns are defined, default to accept
+	if in.domainRules == 0 {
+		return newScopeDecision(item, RuleDefaultAccept, "no in-scope patterns defined, accepting by default")
+	}
+
+	// Domain doesn't match any in-scope pattern
+	if len(in.publicSuffixes) > 0 {
+		return newScopeDecision(item, RuleNoMatch, fmt.Sprintf(
+			"domain does not match any in-scope pattern (public suffix patterns ignored: %s)",
+			strings.Join(in.publicSuffixes, ", ")))
+	}
+	return newScopeDecision(item, RuleNoMatch, "domain does not match any in-scope pattern")
+}
+
+// ShouldAcceptIP determines if an IP address should be accepted based on the
+// IP, CIDR, range and ASN rules of the scope, with the same precedence as
+// ShouldAcceptDomain
+func (sm *ScopeManager) ShouldAcceptIP(ip string) ScopeDecision {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return newScopeDecision(ip, RuleInvalid, "not a valid IP address")
+	}
+	addr = addr.Unmap()
+	in, out := sm.matchers()
+
+	if match, ok := out.matchAddr(addr); ok {
+		reason := "IP matches out-of-scope rule"
+		if match.Type == MatchASN {
+			reason = "IP is announced by out-of-scope ASN"
+		}
+		return newScopeDecision(ip, RuleOutOfScope, reason).matched("out", match)
+	}
+
+	if in.negations != nil {
+		if match, ok := in.negations.matchAddr(addr); ok {
+			match.Pattern = "!" + match.Pattern
+			reason := "IP matches negated in-scope rule"
+			if match.Type == MatchASN {
+				reason = "IP is announced by negated in-scope ASN"
+			}
+			return newScopeDecision(ip, RuleNegated, reason).matched("in", match)
+		}
+	}
+
+	if match, ok := in.matchAddr(addr); ok {
+		reason := "IP matches in-scope rule"
+		if match.Type == MatchASN {
+			reason = "IP is announced by in-scope ASN"
+		}
+		return newScopeDecision(ip, RuleInScope, reason).matched("in", match)
+	}
+
+	// If no in-scope IP rules are defined, default to accept. ASN rules only
+	// count when the dataset is available to resolve them.
+	inScopeRules := len(in.ipRules)
+	if sm.asnPrefixes != nil {
+		inScopeRules += len(in.asns)
+	}
+	if inScopeRules == 0 {
+		return newScopeDecision(ip, RuleDefaultAccept, "no in-scope IP rules defined, accepting by default")
+	}
+
+	return newScopeDecision(ip, RuleNoMatch, "IP does not match any in-scope rule")
+}
+
+// ShouldAcceptASN determines if an ASN should be accepted based on the AS<number>
+// rules of the scope, with the same precedence as ShouldAcceptDomain
+func (sm *ScopeManager) ShouldAcceptASN(asn string) ScopeDecision {
+	number, ok := parseASN(asn)
+	if !ok {
+		return newScopeDecision(asn, RuleInvalid, "not a valid ASN")
+	}
+	in, out := sm.matchers()
+
+	if match, ok := out.matchASN(number); ok {
+		return newScopeDecision(asn, RuleOutOfScope, "ASN matches out-of-scope rule").matched("out", match)
+	}
+
+	if in.negations != nil {
+		if match, ok := in.negations.matchASN(number); ok {
+			match.Pattern = "!" + match.Pattern
+			return newScopeDecision(asn, RuleNegated, "ASN matches negated in-scope rule").matched("in", match)
+		}
+	}
+
+	if match, ok := in.matchASN(number); ok {
+		return newScopeDecision(asn, RuleInScope, "ASN matches in-scope rule").matched("in", match)
+	}
+
+	if len(in.asns) == 0 {
+		return newScopeDecision(asn, RuleDefaultAccept, "no in-scope ASN rules defined, accepting by default")
+	}
+
+	return newScopeDecision(asn, RuleNoMatch, "ASN does not match any in-scope rule")
+}
+
+// Decide evaluates an item with ShouldAcceptURL, ShouldAcceptIP,
+// ShouldAcceptASN or ShouldAcceptDomain depending on what it looks like
+func (sm *ScopeManager) Decide(item string) ScopeDecision {
+	if isURLRule(item) {
+		return sm.ShouldAcceptURL(item)
+	}
+	if _, err := netip.ParseAddr(strings.TrimSpace(item)); err == nil {
+		return sm.ShouldAcceptIP(item)
+	}
+	if isASNRule(item) {
+		return sm.ShouldAcceptASN(item)
+	}
+	return sm.ShouldAcceptDomain(item)
+}
+
+// IsASNInScope reports whether asn is listed as an in-scope AS<number> rule
+func (sm *ScopeManager) IsASNInScope(asn uint32) bool {
+	in, _ := sm.matchers()
+	_, ok := in.matchASN(asn)
+	return ok
+}
+
+// IsASNOutOfScope reports whether asn is listed as an out-of-scope AS<number> rule
+func (sm *ScopeManager) IsASNOutOfScope(asn uint32) bool {
+	_, out := sm.matchers()
+	_, ok := out.matchASN(asn)
+	return ok
+}
+
+// IsIPInScope reports whether ip falls inside any in-scope IP, CIDR or range rule
+func (sm *ScopeManager) IsIPInScope(ip string) bool {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false
+	}
+	in, _ := sm.matchers()
+	_, ok := in.matchIP(addr.Unmap())
+	return ok
+}
+
+// IsIPOutOfScope reports whether ip falls inside any out-of-scope IP, CIDR or range rule
+func (sm *ScopeManager) IsIPOutOfScope(ip string) bool {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false
+	}
+	_, out := sm.matchers()
+	_, ok := out.matchIP(addr.Unmap())
+	return ok
+}
+
+func (sm *ScopeManager) IsInScope(domain string) bool {
+	in, _ := sm.matchers()
+	_, ok := in.matchDomain(strings.ToLower(strings.TrimSpace(domain)))
+	return ok
+}
+
+func (sm *ScopeManager) IsOutOfScope(domain string) bool {
+	_, out := sm.matchers()
+	_, ok := out.matchDomain(strings.ToLower(strings.TrimSpace(domain)))
+	return ok
+}
+
+// Compile builds the matchers used by the ShouldAccept* and Is* methods. It is
+// called by LoadScope and must be called again after InScope or OutScope are
+// modified by hand.
+func (sm *ScopeManager) Compile() {
+	// Public suffix patterns are only ignored in scope; excluding a whole
+	// suffix out of scope is harmless
+	sm.in = compileScopeList(sm.InScope, sm.asnPrefixes, true)
+	sm.out = compileScopeList(sm.OutScope, sm.asnPrefixes, false)
+}
+
+// matchers returns the compiled in- and out-of-scope lists, compiling them on
+// first use for managers that were populated without LoadScope
+func (sm *ScopeManager) matchers() (*scopeMatcher, *scopeMatcher) {
+	sm.compileOnce.Do(func() {
+		if sm.in == nil || sm.out == nil {
+			sm.Compile()
+		}
+	})
+	return sm.in, sm.out
+}
+
+// matchesPattern is the uncompiled reference matcher for a single
+// domain/pattern pair. The compiled matcher in scope_matcher.go must agree
+// with it, as TestCompiledMatchesReference checks. Negated patterns never match
+// here, the caller checks them with the ! removed.
+func (sm *ScopeManager) matchesPattern(domain, pattern string) bool {
+	pattern = strings.TrimSpace(pattern)
+	domain = strings.ToLower(strings.TrimSpace(domain))
+
+	if strings.HasPrefix(pattern, "!") {
+		return false
+	}
+
+	// Regex patterns are case sensitive in their syntax, so not lowercased
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		regex, err := compileScopeRegex(expr)
+		return err == nil && regex.MatchString(domain)
+	}
+
+	pattern = strings.ToLower(pattern)
+
+	// IP, CIDR, range and ASN rules never match hostnames
+	if isIPRule(pattern) || isASNRule(pattern) {
+		return false
+	}
+
+	// Exact-only match, no implicit subdomains
+	if host, ok := strings.CutPrefix(pattern, "="); ok {
+		if strings.Contains(host, "*") {
+			regex, err := regexp.Compile(wildcardToRegex(host))
+			return err == nil && regex.MatchString(domain)
+		}
+		return host == domain
+	}
+
+	// Exact match
+	if pattern == domain {
+		return true
+	}
+
+	// Wildcard pattern matching
+	if strings.Contains(pattern, "*") {
+		return sm.matchesWildcard(domain, pattern)
+	}
+
+	// Subdomain matching (implicit wildcard)
+	if strings.HasSuffix(domain, "."+pattern) {
+		return true
+	}
+
+	return false
+}
+
+func (sm *ScopeManager) matchesWildcard(domain, pattern string) bool {
+	// Handle *.example.com patterns
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[2:] // Remove "*."
+		return domain == suffix || strings.HasSuffix(domain, "."+suffix)
+	}
+
+	// Handle other wildcard patterns like sub.*.example.com
+	regex, err := regexp.Compile(wildcardToRegex(pattern))
+	if err != nil {
+		// Fallback to simple glob matching
+		matched, _ := filepath.Match(pattern, domain)
+		return matched
+	}
+
+	return regex.MatchString(domain)
+}
+
+// compileScopeRegex compiles the expression of a re: pattern. It must match
+// the whole domain and is case insensitive.
+func compileScopeRegex(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)^(?:" + expr + ")$")
+}
+
+// wildcardToRegex converts a wildcard pattern such as sub.*.example.com into
+// an anchored regular expression
+func wildcardToRegex(pattern string) string {
+	escapedPattern := regexp.QuoteMeta(pattern)
+	// Replace escaped \* with .* for regex matching
+	regexPattern := strings.ReplaceAll(escapedPattern, "\\*", ".*")
+	// Anchor the pattern
+	return "^" + regexPattern + "$"
+}
+
+// ipRange is an inclusive range of addresses parsed from an IP, CIDR or range rule
+type ipRange struct {
+	from netip.Addr
+	to   netip.Addr
+}
+
+func (r ipRange) contains(addr netip.Addr) bool {
+	return r.from.Compare(addr) <= 0 && addr.Compare(r.to) <= 0
+}
+
+// parseIPRule parses a scope rule in one of the following forms:
+//
+//	10.0.0.1              single address
+//	10.0.0.0/24           CIDR block
+//	10.0.0.1-10.0.0.50    explicit range
+//	10.0.0.1-50           short range (last octet only)
+func parseIPRule(pattern string) (ipRange, bool) {
+	pattern = strings.TrimSpace(pattern)
+
+	if strings.Contains(pattern, "/") {
+		prefix, err := netip.ParsePrefix(pattern)
+		if err != nil {
+			return ipRange{}, false
+		}
+		prefix = prefix.Masked()
+		return ipRange{from: prefix.Addr(), to: lastAddrInPrefix(prefix)}, true
+	}
+
+	if start, end, found := strings.Cut(pattern, "-"); found {
+		from, err := netip.ParseAddr(strings.TrimSpace(start))
+		if err != nil {
+			return ipRange{}, false
+		}
+		end = strings.TrimSpace(end)
+		to, err := netip.ParseAddr(end)
+		if err != nil && from.Is4() && !strings.Contains(end, ".") {
+			// Short form: only the last octet is given
+			octets := from.As4()
+			to, err = netip.ParseAddr(fmt.Sprintf("%d.%d.%d.%s", octets[0], octets[1], octets[2], end))
+		}
+		if err != nil || from.BitLen() != to.BitLen() || to.Less(from) {
+			return ipRange{}, false
+		}
+		return ipRange{from: from, to: to}, true
+	}
+
+	addr, err := netip.ParseAddr(pattern)
+	if err != nil {
+		return ipRange{}, false
+	}
+	return ipRange{from: addr, to: addr}, true
+}
+
+func lastAddrInPrefix(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 1 << (7 - uint(bit%8))
+	}
+	last, _ := netip.AddrFromSlice(bytes)
+	return last
+}
+
+func isIPRule(pattern string) bool {
+	_, ok := parseIPRule(pattern)
+	return ok
+}
+
+func countIPRules(patterns []string) int {
+	n := 0
+	for _, pattern := range patterns {
+		if isIPRule(pattern) {
+			n++
+		}
+	}
+	return n
+}
+
+func countDomainPatterns(patterns []string) int {
+	return len(patterns) - countIPRules(patterns) - countASNRules(patterns)
+}
+
+// parseASNRule parses an AS<number> scope rule. The AS prefix is required so
+// that plain numbers are never mistaken for ASN scope.
+func parseASNRule(pattern string) (uint32, bool) {
+	pattern = strings.TrimSpace(pattern)
+	if len(pattern) < 3 || !strings.EqualFold(pattern[:2], "AS") {
+		return 0, false
+	}
+	return parseASN(pattern)
+}
+
+// parseASN parses an ASN with or without the AS prefix (AS13335, as13335, 13335)
+func parseASN(item string) (uint32, bool) {
+	item = strings.TrimSpace(item)
+	if len(item) >= 2 && strings.EqualFold(item[:2], "AS") {
+		item = item[2:]
+	}
+	number, err := strconv.ParseUint(item, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(number), true
+}
+
+func isASNRule(pattern string) bool {
+	_, ok := parseASNRule(pattern)
+	return ok
+}
+
+func countASNRules(patterns []string) int {
+	n := 0
+	for _, pattern := range patterns {
+		if isASNRule(pattern) {
+			n++
+		}
+	}
+	return n
+}
+
+// extractIP strips an optional port (1.2.3.4:80, [::1]:443) from an IP item
+func extractIP(item string) string {
+	item = strings.TrimSpace(item)
+	if _, err := netip.ParseAddr(item); err == nil {
+		return item
+	}
+	if addrPort, err := netip.ParseAddrPort(item); err == nil {
+		return addrPort.Addr().String()
+	}
+	return strings.Split(item, ":")[0]
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultScopeCacheTTL is how long cached scope rules are used without asking
+// the server
+const defaultScopeCacheTTL = 5 * time.Minute
+
+// scopeCache is the cached scope of one company on one server
+type scopeCache struct {
+	Company   string    `json:"company"`
+	API       string    `json:"api"`
+	FetchedAt time.Time `json:"fetched_at"`
+	InScope   []string  `json:"inscope"`
+	OutScope  []string  `json:"outscope"`
+	InETag    string    `json:"in_etag,omitempty"`
+	OutETag   string    `json:"out_etag,omitempty"`
+}
+
+// scopeCacheTTL returns the cache TTL. --scope-cache-ttl overrides
+// scope_cache_ttl from the config file.
+func scopeCacheTTL() time.Duration {
+	if rootCmd.PersistentFlags().Changed("scope-cache-ttl") {
+		return scopeCacheTTLFlag
+	}
+	if config.ScopeCacheTTL != "" {
+		if ttl, err := time.ParseDuration(config.ScopeCacheTTL); err == nil {
+			return ttl
+		}
+		fmt.Printf("%s Invalid scope_cache_ttl %q in config, using %s\n", warning("⚠️"), config.ScopeCacheTTL, defaultScopeCacheTTL)
+	}
+	return defaultScopeCacheTTL
+}
+
+// serverProfile names the configured server for local state, so profiles
+// pointing at different servers don't share cached scope or history
+func serverProfile() string {
+	if u, err := url.Parse(config.API); err == nil && u.Host != "" {
+		return strings.ReplaceAll(u.Host, ":", "_")
+	}
+	return "default"
+}
+
+// scopeCacheDir returns the cache directory of the configured server
+func scopeCacheDir() string {
+	return filepath.Join(filepath.Dir(configPath), "cache", "scope", serverProfile())
+}
+
+// scopeCachePath returns the cache file of a company
+func scopeCachePath(company string) string {
+	return filepath.Join(scopeCacheDir(), url.PathEscape(company)+".json")
+}
+
+// readScopeCache reads the cached scope of a company
+func readScopeCache(company string) (*scopeCache, error) {
+	content, err := os.ReadFile(scopeCachePath(company))
+	if err != nil {
+		return nil, err
+	}
+
+	var cache scopeCache
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return nil, err
+	}
+	if cache.Company != company || cache.API != config.API {
+		return nil, fmt.Errorf("cache entry belongs to %s on %s", cache.Company, cache.API)
+	}
+	return &cache, nil
+}
+
+// writeScopeCache stores the scope of a company
+func writeScopeCache(cache *scopeCache) error {
+	if err := os.MkdirAll(scopeCacheDir(), 0700); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(scopeCachePath(cache.Company), content, 0600)
+}
+
+// invalidateScopeCache drops the cached scope of a company
+func invalidateScopeCache(company string) {
+	os.Remove(scopeCachePath(company))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scopeTestServer serves the scope lists with an ETag per list and counts the
+// requests and the conditional requests answered with 304
+type scopeTestServer struct {
+	lists        map[string][]string
+	etags        map[string]string
+	requests     atomic.Int32
+	notModifieds atomic.Int32
+}
+
+func (s *scopeTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	list := r.URL.Query().Get("type")
+	if etag := s.etags[list]; etag != "" && r.Header.Get("If-None-Match") == etag {
+		s.notModifieds.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etags[list])
+	json.NewEncoder(w).Encode(s.lists[list])
+}
+
+// withScopeCache points the config at a test server and the cache at a
+// temporary directory
+func withScopeCache(t *testing.T, handler http.Handler, ttl string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	oldConfig, oldPath := config, configPath
+	t.Cleanup(func() { config, configPath = oldConfig, oldPath })
+	config = Config{API: server.URL, Token: "test", ScopeCacheTTL: ttl}
+	configPath = filepath.Join(t.TempDir(), "config.json")
+}
+
+func TestLoadScopeUsesFreshCache(t *testing.T) {
+	server := &scopeTestServer{
+		lists: map[string][]string{"in": {"*.example.com"}, "out": {"admin.example.com"}},
+		etags: map[string]string{"in": `"in-1"`, "out": `"out-1"`},
+	}
+	withScopeCache(t, server, "1h")
+
+	sm := NewScopeManager("acme")
+	if err := sm.LoadScope(); err != nil {
+		t.Fatal(err)
+	}
+	if server.requests.Load() != 2 {
+		t.Fatalf("first load made %d requests, want 2", server.requests.Load())
+	}
+
+	// Changes on the server aren't seen until the cache expires
+	server.lists["in"] = []string{"*.example.org"}
+	sm = NewScopeManager("acme")
+	if err := sm.LoadScope(); err != nil {
+		t.Fatal(err)
+	}
+	if server.requests.Load() != 2 {
+		t.Errorf("load within the TTL made %d requests, want none", server.requests.Load()-2)
+	}
+	if !reflect.DeepEqual(sm.InScope, []string{"*.example.com"}) {
+		t.Errorf("in scope %v, want the cached *.example.com", sm.InScope)
+	}
+	if !sm.ShouldAcceptDomain("www.example.com").Accepted {
+		t.Error("rules loaded from the cache are not compiled")
+	}
+
+	// A refresh ignores the cache
+	if err := sm.RefreshScope(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sm.InScope, []string{"*.example.org"}) {
+		t.Errorf("in scope after refresh %v, want *.example.org", sm.InScope)
+	}
+}
+
+func TestLoadScopeRevalidatesStaleCache(t *testing.T) {
+	server := &scopeTestServer{
+		lists: map[string][]string{"in": {"*.example.com"}, "out": {"admin.example.com"}},
+		etags: map[string]string{"in": `"in-1"`, "out": `"out-1"`},
+	}
+	withScopeCache(t, server, "0s")
+
+	if err := NewScopeManager("acme").LoadScope(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged lists are answered with 304 and kept from the cache
+	server.lists["in"] = nil
+	sm := NewScopeManager("acme")
+	if err := sm.LoadScope(); err != nil {
+		t.Fatal(err)
+	}
+	if server.notModifieds.Load() != 2 {
+		t.Errorf("%d lists revalidated, want 2", server.notModifieds.Load())
+	}
+	if !reflect.DeepEqual(sm.InScope, []string{"*.example.com"}) || !reflect.DeepEqual(sm.OutScope, []string{"admin.example.com"}) {
+		t.Errorf("scope %v / %v, want the cached lists", sm.InScope, sm.OutScope)
+	}
+
+	// A changed list comes with a new ETag and replaces the cached one
+	server.lists["in"] = []string{"*.example.org"}
+	server.etags["in"] = `"in-2"`
+	sm = NewScopeManager("acme")
+	if err := sm.LoadScope(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sm.InScope, []string{"*.example.org"}) {
+		t.Errorf("in scope %v, want the changed *.example.org", sm.InScope)
+	}
+	cache, err := readScopeCache("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.InETag != `"in-2"` || cache.OutETag != `"out-1"` {
+		t.Errorf("cached ETags %s / %s, want \"in-2\" / \"out-1\"", cache.InETag, cache.OutETag)
+	}
+}
+
+func TestReadScopeCache(t *testing.T) {
+	withScopeCache(t, http.NotFoundHandler(), "")
+
+	if _, err := readScopeCache("acme"); err == nil {
+		t.Error("missing cache entry: no error")
+	}
+	if err := writeScopeCache(&scopeCache{Company: "acme", API: config.API, FetchedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readScopeCache("acme"); err != nil {
+		t.Errorf("cache entry not read back: %v", err)
+	}
+
+	// Entries written for another server aren't used
+	config.API = "https://other.example.com"
+	if _, err := readScopeCache("acme"); err == nil {
+		t.Error("cache entry of another server: no error")
+	}
+
+	if got := scopeCacheTTL(); got != defaultScopeCacheTTL {
+		t.Errorf("TTL without config %v, want %v", got, defaultScopeCacheTTL)
+	}
+	config.ScopeCacheTTL = "10m"
+	if got := scopeCacheTTL(); got != 10*time.Minute {
+		t.Errorf("TTL from config %v, want 10m", got)
+	}
+}
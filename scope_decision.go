@@ -0,0 +1,87 @@
+package main
+
+import "fmt"
+
+// MatchType describes how a scope pattern matched an item
+type MatchType string
+
+const (
+	MatchExact             MatchType = "exact"
+	MatchWildcard          MatchType = "wildcard"
+	MatchImplicitSubdomain MatchType = "implicit subdomain"
+	MatchRegex             MatchType = "regex"
+	MatchCIDR              MatchType = "cidr"
+	MatchIPRange           MatchType = "ip range"
+	MatchASN               MatchType = "asn"
+	MatchURL               MatchType = "url"
+)
+
+// ScopeRule is a step of the precedence order documented on
+// ShouldAcceptDomain. Lower steps win.
+type ScopeRule int
+
+const (
+	RuleInvalid ScopeRule = iota
+	RuleOutOfScope
+	RuleNegated
+	RuleInScope
+	RuleDefaultAccept
+	RuleNoMatch
+)
+
+func (r ScopeRule) String() string {
+	switch r {
+	case RuleOutOfScope:
+		return "out-of-scope match"
+	case RuleNegated:
+		return "negated in-scope match"
+	case RuleInScope:
+		return "in-scope match"
+	case RuleDefaultAccept:
+		return "no in-scope rules, accept by default"
+	case RuleNoMatch:
+		return "no in-scope match"
+	default:
+		return "invalid input"
+	}
+}
+
+// ScopeDecision is the outcome of evaluating one item against the scope,
+// including which rule fired and why
+type ScopeDecision struct {
+	Item       string    `json:"item"`
+	Accepted   bool      `json:"accepted"`
+	Reason     string    `json:"reason"`
+	Rule       string    `json:"rule"`
+	Precedence ScopeRule `json:"precedence"`
+	Pattern    string    `json:"pattern,omitempty"`
+	List       string    `json:"list,omitempty"`
+	MatchType  MatchType `json:"match_type,omitempty"`
+}
+
+func newScopeDecision(item string, rule ScopeRule, reason string) ScopeDecision {
+	return ScopeDecision{
+		Item:       item,
+		Accepted:   rule == RuleInScope || rule == RuleDefaultAccept,
+		Reason:     reason,
+		Rule:       rule.String(),
+		Precedence: rule,
+	}
+}
+
+// matched records the pattern that decided the item and the list it is in
+// ("in" or "out", as used by scope show)
+func (d ScopeDecision) matched(list string, match scopeMatch) ScopeDecision {
+	d.List = list
+	d.Pattern = match.Pattern
+	d.MatchType = match.Type
+	return d
+}
+
+// Detail is the reason followed by the deciding pattern, if there is one
+func (d ScopeDecision) Detail() string {
+	if d.Pattern == "" {
+		return d.Reason
+	}
+	return fmt.Sprintf("%s: %s (%s)", d.Reason, d.Pattern, d.MatchType)
+}
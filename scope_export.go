@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// scopeExportFormats lists the formats of scope export --format
+var scopeExportFormats = []string{"yaml", "burp", "regex", "nuclei", "hosts"}
+
+// scopePatternRegex converts a domain pattern into an anchored regex with the
+// same meaning as matchesPattern, case insensitive like it through a (?i)
+// prefix. The regex sticks to syntax shared by RE2, Java (Burp) and PCRE
+// (grep -P). Non-domain patterns return false.
+func scopePatternRegex(pattern string) (string, bool) {
+	pattern = strings.TrimSpace(pattern)
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		if _, err := compileScopeRegex(expr); err != nil {
+			return "", false
+		}
+		return "(?i)^(" + expr + ")$", true
+	}
+
+	pattern = strings.ToLower(pattern)
+	if pattern == "" || isIPRule(pattern) || isASNRule(pattern) || isURLRule(pattern) {
+		return "", false
+	}
+
+	if host, ok := strings.CutPrefix(pattern, "="); ok {
+		return "(?i)" + wildcardToRegex(host), true
+	}
+
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		// *.example.com also matches example.com, see matchesWildcard
+		return "(?i)^(.*\\.)?" + regexp.QuoteMeta(suffix) + "$", true
+	}
+	if strings.Contains(pattern, "*") {
+		return "(?i)" + wildcardToRegex(pattern), true
+	}
+	// Implicit subdomain matching
+	return "(?i)^(.*\\.)?" + regexp.QuoteMeta(pattern) + "$", true
+}
+
+// exportScopeRegex combines domain patterns into one regex. For the in list
+// it covers the positive in-scope patterns; for the out list it covers
+// everything to exclude: out-of-scope patterns and !negations in the in-scope
+// list. Go's RE2 has no lookahead, so the two can't be merged into one.
+func exportScopeRegex(inScope, outScope []string, list string) string {
+	var parts []string
+	seen := make(map[string]bool)
+	add := func(pattern string) {
+		if regex, ok := scopePatternRegex(pattern); ok && !seen[regex] {
+			seen[regex] = true
+			parts = append(parts, "("+regex+")")
+		}
+	}
+
+	for _, pattern := range inScope {
+		negated, isNegated := strings.CutPrefix(strings.TrimSpace(pattern), "!")
+		// Public suffix patterns never match in scope, see ShouldAcceptDomain
+		if !isNegated && publicSuffixPattern(pattern) {
+			continue
+		}
+		if isNegated == (list == "out") {
+			add(negated)
+		}
+	}
+	if list == "out" {
+		for _, pattern := range outScope {
+			if !strings.HasPrefix(strings.TrimSpace(pattern), "!") {
+				add(pattern)
+			}
+		}
+	}
+
+	return strings.Join(parts, "|")
+}
+
+type burpScopeEntry struct {
+	Enabled  bool   `json:"enabled"`
+	File     string `json:"file,omitempty"`
+	Host     string `json:"host"`
+	Port     string `json:"port,omitempty"`
+	Protocol string `json:"protocol"`
+}
+
+// exportBurpScope renders a Burp Suite project options file with an advanced
+// mode target scope. Domain patterns become host regexes, networks become
+// Burp IP ranges.
+func exportBurpScope(inScope, outScope []string) ([]byte, error) {
+	include := []burpScopeEntry{}
+	exclude := []burpScopeEntry{}
+
+	seen := map[bool]map[string]bool{true: {}, false: {}}
+	entries := func(pattern string, included bool) []burpScopeEntry {
+		if isURLRule(pattern) {
+			var result []burpScopeEntry
+			for _, entry := range burpURLEntries(pattern) {
+				key := entry.Protocol + " " + entry.Host + " " + entry.Port + " " + entry.File
+				if !seen[included][key] {
+					seen[included][key] = true
+					result = append(result, entry)
+				}
+			}
+			return result
+		}
+
+		var hosts []string
+		if r, ok := parseIPRule(pattern); ok {
+			for _, prefix := range ipRangePrefixes(r) {
+				hosts = append(hosts, prefix.String())
+			}
+		} else if regex, ok := scopePatternRegex(pattern); ok {
+			hosts = append(hosts, regex)
+		}
+
+		var result []burpScopeEntry
+		for _, host := range hosts {
+			if !seen[included][host] {
+				seen[included][host] = true
+				result = append(result, burpScopeEntry{Enabled: true, Host: host, Protocol: "any"})
+			}
+		}
+		return result
+	}
+
+	for _, pattern := range inScope {
+		if negated, ok := strings.CutPrefix(strings.TrimSpace(pattern), "!"); ok {
+			exclude = append(exclude, entries(negated, false)...)
+		} else if !publicSuffixPattern(pattern) {
+			include = append(include, entries(pattern, true)...)
+		}
+	}
+	for _, pattern := range outScope {
+		if !strings.HasPrefix(strings.TrimSpace(pattern), "!") {
+			exclude = append(exclude, entries(pattern, false)...)
+		}
+	}
+
+	project := map[string]interface{}{
+		"target": map[string]interface{}{
+			"scope": map[string]interface{}{
+				"advanced_mode": true,
+				"include":       include,
+				"exclude":       exclude,
+			},
+		},
+	}
+	return json.MarshalIndent(project, "", "    ")
+}
+
+// burpURLEntries converts a URL rule into Burp entries restricting protocol,
+// host, port and file, one per prefix for network hosts
+func burpURLEntries(pattern string) []burpScopeEntry {
+	rule, err := parseURLRule(pattern)
+	if err != nil {
+		return nil
+	}
+
+	entry := burpScopeEntry{Enabled: true, Protocol: "any"}
+	if rule.scheme == "http" || rule.scheme == "https" {
+		entry.Protocol = rule.scheme
+	}
+	if rule.port != "" {
+		entry.Port = "^" + rule.port + "$"
+	}
+	switch {
+	case rule.pathRegex != nil:
+		entry.File = rule.pathRegex.String()
+	case rule.path != "":
+		entry.File = "^" + regexp.QuoteMeta(rule.path) + "(/.*)?$"
+	}
+
+	var hosts []string
+	if rule.hostPattern == "" {
+		hosts = []string{".*"}
+	} else if r, ok := parseIPRule(rule.hostPattern); ok {
+		for _, prefix := range ipRangePrefixes(r) {
+			hosts = append(hosts, prefix.String())
+		}
+	} else if regex, ok := scopePatternRegex(rule.hostPattern); ok {
+		hosts = []string{regex}
+	}
+
+	var entries []burpScopeEntry
+	for _, host := range hosts {
+		entry.Host = host
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// exportHostList derives plain targets from the in-scope patterns, for tools
+// that can't read wildcards. Wildcards are reduced to their base domain,
+// regexes and inner wildcards are skipped, and derived hosts that are out of
+// scope are dropped. Networks are included as CIDRs when withNetworks is set.
+// Skipped patterns are returned with the reason.
+func exportHostList(sm *ScopeManager, withNetworks bool) (hosts []string, skipped []string) {
+	seen := make(map[string]bool)
+	add := func(host string) {
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+
+	for _, raw := range sm.InScope {
+		pattern := strings.ToLower(strings.TrimSpace(raw))
+		switch {
+		case pattern == "" || strings.HasPrefix(pattern, "!"):
+			continue
+		case strings.HasPrefix(pattern, "re:"), isASNRule(pattern), isURLRule(pattern):
+			skipped = append(skipped, raw+": no plain host equivalent")
+		case isIPRule(pattern):
+			if !withNetworks {
+				skipped = append(skipped, raw+": networks are only included in the nuclei format")
+				continue
+			}
+			r, _ := parseIPRule(pattern)
+			for _, prefix := range ipRangePrefixes(r) {
+				if prefix.IsSingleIP() {
+					add(prefix.Addr().String())
+				} else {
+					add(prefix.String())
+				}
+			}
+		default:
+			host := strings.TrimPrefix(strings.TrimPrefix(pattern, "="), "*.")
+			if strings.Contains(host, "*") {
+				skipped = append(skipped, raw+": no plain host equivalent")
+				continue
+			}
+			if decision := sm.ShouldAcceptDomain(host); decision.Accepted {
+				add(host)
+			} else {
+				skipped = append(skipped, raw+": "+decision.Detail())
+			}
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts, skipped
+}
+
+// ipRangePrefixes splits an address range into the smallest list of CIDR
+// prefixes covering exactly the range
+func ipRangePrefixes(r ipRange) []netip.Prefix {
+	var prefixes []netip.Prefix
+	from := r.from
+	for from.IsValid() && !r.to.Less(from) {
+		bits := from.BitLen()
+		// Grow the prefix while it stays aligned and inside the range
+		for bits > 0 {
+			candidate, err := from.Prefix(bits - 1)
+			if err != nil || candidate.Addr() != from || r.to.Less(lastAddrInPrefix(candidate)) {
+				break
+			}
+			bits--
+		}
+		prefix := netip.PrefixFrom(from, bits)
+		prefixes = append(prefixes, prefix)
+
+		last := lastAddrInPrefix(prefix)
+		if last == r.to {
+			break
+		}
+		from = last.Next()
+	}
+	return prefixes
+}
+
+// renderScopeExport produces the output of scope export in a non-YAML format
+func renderScopeExport(format, list string, sm *ScopeManager) (string, []string, error) {
+	switch format {
+	case "burp":
+		content, err := exportBurpScope(sm.InScope, sm.OutScope)
+		return string(content) + "\n", nil, err
+	case "regex":
+		return exportScopeRegex(sm.InScope, sm.OutScope, list) + "\n", nil, nil
+	case "nuclei", "hosts":
+		hosts, skipped := exportHostList(sm, format == "nuclei")
+		if len(hosts) == 0 {
+			return "", skipped, nil
+		}
+		return strings.Join(hosts, "\n") + "\n", skipped, nil
+	}
+	return "", nil, fmt.Errorf("unknown format %s (use %s)", format, strings.Join(scopeExportFormats, ", "))
+}
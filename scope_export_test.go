@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/netip"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestScopePatternRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		ok      bool
+	}{
+		{"example.com", `(?i)^(.*\.)?example\.com$`, true},
+		{"*.Example.com", `(?i)^(.*\.)?example\.com$`, true},
+		{"=www.example.com", `(?i)^www\.example\.com$`, true},
+		{"api*.example.com", `(?i)^api.*\.example\.com$`, true},
+		{`re:API\.example\.(com|org)`, `(?i)^(API\.example\.(com|org))$`, true},
+		{"re:(unclosed", "", false},
+		{"10.0.0.0/24", "", false},
+		{"AS13335", "", false},
+		{"https://example.com/api", "", false},
+		{"  ", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := scopePatternRegex(tt.pattern)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("scopePatternRegex(%q) = %q, %v, want %q, %v", tt.pattern, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// The regexes accept what matchesPattern accepts, in any case
+	sm := NewScopeManager("test")
+	domains := []string{"example.com", "WWW.Example.com", "api.example.org", "API-v2.EXAMPLE.com", "notexample.com", "other.net"}
+	for _, pattern := range []string{"example.com", "*.example.com", "=www.example.com", "api*.example.com", `re:api(-v\d+)?\.example\.(com|org)`} {
+		expr, _ := scopePatternRegex(pattern)
+		re := regexp.MustCompile(expr)
+		for _, domain := range domains {
+			if got, want := re.MatchString(domain), sm.matchesPattern(domain, pattern); got != want {
+				t.Errorf("%q on %s: regex %v, matchesPattern %v", pattern, domain, got, want)
+			}
+		}
+	}
+}
+
+func TestExportScopeRegex(t *testing.T) {
+	inScope := []string{"*.example.com", "example.com", "!staging.example.com", "co.uk", "10.0.0.0/8", `re:api\d\.example\.org`}
+	outScope := []string{"admin.example.com", "!ignored.example.com"}
+
+	tests := []struct {
+		list string
+		want string
+	}{
+		{"in", `((?i)^(.*\.)?example\.com$)|((?i)^(api\d\.example\.org)$)`},
+		{"out", `((?i)^(.*\.)?staging\.example\.com$)|((?i)^(.*\.)?admin\.example\.com$)`},
+	}
+	for _, tt := range tests {
+		if got := exportScopeRegex(inScope, outScope, tt.list); got != tt.want {
+			t.Errorf("%s list: got %s, want %s", tt.list, got, tt.want)
+		}
+	}
+
+	// The flag stays inside its alternative
+	in := regexp.MustCompile(exportScopeRegex(inScope, outScope, "in"))
+	for domain, want := range map[string]bool{"WWW.Example.COM": true, "API1.example.org": true, "api1.example.org.evil.net": false, "bbc.co.uk": false} {
+		if got := in.MatchString(domain); got != want {
+			t.Errorf("in regex on %s: %v, want %v", domain, got, want)
+		}
+	}
+
+	if got := exportScopeRegex([]string{"10.0.0.0/8"}, nil, "in"); got != "" {
+		t.Errorf("no domain patterns: got %q, want empty", got)
+	}
+}
+
+func TestIPRangePrefixes(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     []string
+	}{
+		{"10.0.0.0", "10.0.0.255", []string{"10.0.0.0/24"}},
+		{"10.0.0.5", "10.0.0.5", []string{"10.0.0.5/32"}},
+		{"10.0.0.1", "10.0.0.6", []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"}},
+		{"10.0.0.255", "10.0.1.0", []string{"10.0.0.255/32", "10.0.1.0/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"2001:db8::", "2001:db8::ffff", []string{"2001:db8::/112"}},
+	}
+	for _, tt := range tests {
+		r := ipRange{from: netip.MustParseAddr(tt.from), to: netip.MustParseAddr(tt.to)}
+		var got []string
+		for _, prefix := range ipRangePrefixes(r) {
+			got = append(got, prefix.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s-%s: got %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
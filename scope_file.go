@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScopeFile is the declarative form of a company's scope, as used by
+// scope export and scope apply
+type ScopeFile struct {
+	Company  string   `yaml:"company,omitempty" json:"company,omitempty"`
+	InScope  []string `yaml:"inscope" json:"inscope"`
+	OutScope []string `yaml:"outscope" json:"outscope"`
+}
+
+// ScopeChange is one step of a scope plan
+type ScopeChange struct {
+	List    string // "in" or "out"
+	Pattern string
+	Add     bool
+}
+
+// ScopePlan is the set of changes needed to bring the server in line with a
+// scope file. Removals are applied before additions.
+type ScopePlan struct {
+	Remove []ScopeChange
+	Add    []ScopeChange
+
+	// Rejected holds in-scope additions covering a whole public suffix,
+	// refused as scope inscope refuses them
+	Rejected []ScopeChange
+}
+
+func (p ScopePlan) Empty() bool {
+	return len(p.Remove) == 0 && len(p.Add) == 0
+}
+
+func loadScopeFile(path string) (*ScopeFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ScopeFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	file.InScope = cleanScopePatterns(file.InScope)
+	file.OutScope = cleanScopePatterns(file.OutScope)
+	return &file, nil
+}
+
+func (f *ScopeFile) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# BBRF scope for %s\n# Apply with: bbrf company scope apply -f <file> -c %s\n", f.Company, f.Company)
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cleanScopePatterns trims patterns and drops empty entries
+func cleanScopePatterns(patterns []string) []string {
+	cleaned := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			cleaned = append(cleaned, pattern)
+		}
+	}
+	return cleaned
+}
+
+// scopePatternKey normalizes a pattern for comparison. Hostnames are case
+// insensitive, regexes, negated or not, are not, and neither are the paths
+// of URL rules.
+func scopePatternKey(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(pattern, "!")), "re:") {
+		return pattern
+	}
+	if i := strings.Index(pattern, "://"); i >= 0 {
+		if j := strings.Index(pattern[i+len("://"):], "/"); j >= 0 {
+			end := i + len("://") + j
+			return strings.ToLower(pattern[:end]) + pattern[end:]
+		}
+	}
+	return strings.ToLower(pattern)
+}
+
+// planScope computes the changes turning current into desired
+func planScope(current, desired *ScopeFile) ScopePlan {
+	var plan ScopePlan
+
+	lists := []struct {
+		name             string
+		current, desired []string
+	}{
+		{"in", current.InScope, desired.InScope},
+		{"out", current.OutScope, desired.OutScope},
+	}
+
+	for _, list := range lists {
+		have := make(map[string]bool)
+		for _, pattern := range list.current {
+			have[scopePatternKey(pattern)] = true
+		}
+		want := make(map[string]bool)
+		for _, pattern := range list.desired {
+			key := scopePatternKey(pattern)
+			switch {
+			case have[key] || want[key]:
+			case list.name == "in" && publicSuffixPattern(pattern):
+				plan.Rejected = append(plan.Rejected, ScopeChange{List: list.name, Pattern: pattern, Add: true})
+			default:
+				plan.Add = append(plan.Add, ScopeChange{List: list.name, Pattern: pattern, Add: true})
+			}
+			want[key] = true
+		}
+		for _, pattern := range list.current {
+			if !want[scopePatternKey(pattern)] {
+				plan.Remove = append(plan.Remove, ScopeChange{List: list.name, Pattern: pattern})
+			}
+		}
+	}
+
+	// The remove endpoint isn't list specific, so a pattern removed from one
+	// list but kept in the other has to be added back to that list
+	removed := make(map[string]bool)
+	for _, change := range plan.Remove {
+		removed[scopePatternKey(change.Pattern)] = true
+	}
+	for _, list := range lists {
+		for _, pattern := range list.current {
+			if !removed[scopePatternKey(pattern)] || plan.adds(list.name, pattern) {
+				continue
+			}
+			for _, keep := range list.desired {
+				if scopePatternKey(keep) == scopePatternKey(pattern) {
+					plan.Add = append(plan.Add, ScopeChange{List: list.name, Pattern: keep, Add: true})
+					break
+				}
+			}
+		}
+	}
+
+	return plan
+}
+
+func (p ScopePlan) adds(list, pattern string) bool {
+	for _, change := range p.Add {
+		if change.List == list && scopePatternKey(change.Pattern) == scopePatternKey(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// printScopePlan renders a plan in the style of terraform plan
+func printScopePlan(plan ScopePlan) {
+	for _, change := range plan.Rejected {
+		fmt.Printf("%s %s - would put every domain under a public suffix in scope\n", errorC("❌ REJECTED:"), domainClr(change.Pattern))
+	}
+	if plan.Empty() {
+		fmt.Println(success("✅ No changes. Server scope matches the file."))
+		return
+	}
+
+	listName := map[string]string{"in": "inscope ", "out": "outscope"}
+	for _, change := range plan.Remove {
+		fmt.Printf("  %s %s %s\n", errorC("-"), info(listName[change.List]), domainClr(change.Pattern))
+	}
+	for _, change := range plan.Add {
+		fmt.Printf("  %s %s %s\n", success("+"), info(listName[change.List]), domainClr(change.Pattern))
+	}
+	fmt.Println()
+	fmt.Println(title(fmt.Sprintf("Plan: %d to add, %d to remove.", len(plan.Add), len(plan.Remove))))
+}
+
+// applyScopePlan posts the plan's removals, then its additions grouped per
+// list, and records the result in the scope history under source
+func applyScopePlan(company string, plan ScopePlan, source string) error {
+	defer scopeChanged(company, source)
+
+	// The remove endpoint takes patterns off both lists at once, planScope
+	// adds back the ones that stay on the other list
+	var removals []string
+	for _, change := range plan.Remove {
+		removals = append(removals, change.Pattern)
+	}
+	if len(removals) > 0 {
+		body := map[string]string{"company": company, "domains": strings.Join(removals, " ")}
+		if _, err := apiRequest("POST", "/api/scope/remove", body); err != nil {
+			return fmt.Errorf("removing scope patterns: %w", err)
+		}
+	}
+
+	additions := make(map[string][]string)
+	for _, change := range plan.Add {
+		additions[change.List] = append(additions[change.List], change.Pattern)
+	}
+	for _, list := range []string{"in", "out"} {
+		if len(additions[list]) == 0 {
+			continue
+		}
+		body := map[string]string{"company": company, "domains": strings.Join(additions[list], " ")}
+		if _, err := apiRequest("POST", "/api/scope/"+list, body); err != nil {
+			return fmt.Errorf("adding %s-scope patterns: %w", list, err)
+		}
+	}
+
+	return nil
+}
+
+// fetchScopeFile reads the current scope of a company from the server
+func fetchScopeFile(company string) (*ScopeFile, error) {
+	sm := NewScopeManager(company)
+	inscope, err := sm.fetchScopeFromServer("in")
+	if err != nil {
+		return nil, err
+	}
+	outscope, err := sm.fetchScopeFromServer("out")
+	if err != nil {
+		return nil, err
+	}
+	recordScopeSnapshot(company, inscope, outscope, scopeSourceServer)
+	return &ScopeFile{
+		Company:  company,
+		InScope:  cleanScopePatterns(inscope),
+		OutScope: cleanScopePatterns(outscope),
+	}, nil
+}
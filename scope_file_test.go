@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopePatternKey(t *testing.T) {
+	tests := []struct {
+		pattern, want string
+	}{
+		{"Example.COM", "example.com"},
+		{"  *.Example.com ", "*.example.com"},
+		{"=WWW.example.com", "=www.example.com"},
+		{"!Staging.Example.com", "!staging.example.com"},
+		{`re:API\.Example\.com`, `re:API\.Example\.com`},
+		{`!re:Dev\..*`, `!re:Dev\..*`},
+		{`! re:Dev\..*`, `! re:Dev\..*`},
+		{"https://Store.Example.com:8080/re:X", "https://store.example.com:8080/re:X"},
+		{"HTTPS://*.Example.com/Admin/*", "https://*.example.com/Admin/*"},
+		{"http://API.example.com", "http://api.example.com"},
+		{"Score.re:", "score.re:"},
+	}
+	for _, tt := range tests {
+		if got := scopePatternKey(tt.pattern); got != tt.want {
+			t.Errorf("scopePatternKey(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestPlanScope(t *testing.T) {
+	tests := []struct {
+		name                  string
+		current, desired      ScopeFile
+		add, remove, rejected []ScopeChange
+	}{
+		{
+			name:    "no changes, case insensitive",
+			current: ScopeFile{InScope: []string{"*.Example.com"}, OutScope: []string{"admin.example.com"}},
+			desired: ScopeFile{InScope: []string{"*.example.com"}, OutScope: []string{"ADMIN.example.com"}},
+		},
+		{
+			name:    "add and remove",
+			current: ScopeFile{InScope: []string{"a.com", "b.com"}},
+			desired: ScopeFile{InScope: []string{"b.com", "c.com"}, OutScope: []string{"x.b.com"}},
+			add: []ScopeChange{
+				{List: "in", Pattern: "c.com", Add: true},
+				{List: "out", Pattern: "x.b.com", Add: true},
+			},
+			remove: []ScopeChange{{List: "in", Pattern: "a.com"}},
+		},
+		{
+			name:    "duplicates in the file are added once",
+			current: ScopeFile{},
+			desired: ScopeFile{InScope: []string{"a.com", "A.com"}},
+			add:     []ScopeChange{{List: "in", Pattern: "a.com", Add: true}},
+		},
+		{
+			name:    "removal from one list adds back to the other",
+			current: ScopeFile{InScope: []string{"a.com"}, OutScope: []string{"a.com"}},
+			desired: ScopeFile{InScope: []string{"a.com"}},
+			add:     []ScopeChange{{List: "in", Pattern: "a.com", Add: true}},
+			remove:  []ScopeChange{{List: "out", Pattern: "a.com"}},
+		},
+		{
+			name:    "moving a pattern between lists",
+			current: ScopeFile{InScope: []string{"a.com"}},
+			desired: ScopeFile{OutScope: []string{"a.com"}},
+			add:     []ScopeChange{{List: "out", Pattern: "a.com", Add: true}},
+			remove:  []ScopeChange{{List: "in", Pattern: "a.com"}},
+		},
+		{
+			name:     "public suffixes are refused in scope only",
+			current:  ScopeFile{InScope: []string{"*.com"}},
+			desired:  ScopeFile{InScope: []string{"*.com", "co.uk", "a.co.uk", "corp"}, OutScope: []string{"co.uk"}},
+			add:      []ScopeChange{{List: "in", Pattern: "a.co.uk", Add: true}, {List: "in", Pattern: "corp", Add: true}, {List: "out", Pattern: "co.uk", Add: true}},
+			rejected: []ScopeChange{{List: "in", Pattern: "co.uk", Add: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		plan := planScope(&tt.current, &tt.desired)
+		if !reflect.DeepEqual(plan.Add, tt.add) {
+			t.Errorf("%s: adds %v, want %v", tt.name, plan.Add, tt.add)
+		}
+		if !reflect.DeepEqual(plan.Remove, tt.remove) {
+			t.Errorf("%s: removes %v, want %v", tt.name, plan.Remove, tt.remove)
+		}
+		if !reflect.DeepEqual(plan.Rejected, tt.rejected) {
+			t.Errorf("%s: rejects %v, want %v", tt.name, plan.Rejected, tt.rejected)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of scope filter, grep style
+const (
+	filterAllAccepted  = 0
+	filterSomeRejected = 1
+	filterFailed       = 2
+)
+
+// createScopeFilterCommand builds "scope filter", which applies the scope
+// rules to arbitrary tool output without storing anything
+func createScopeFilterCommand() *cobra.Command {
+	filterCmd := &cobra.Command{
+		Use:   "filter [- | files...]",
+		Short: "🚰 Filter lines of domains, URLs or host:port through the scope rules",
+		Long: `Read domains, IPs, URLs or host:port lines from stdin or files, and write
+the lines whose host is in scope to stdout unchanged. Only the first field of
+each line is looked at, so annotated tool output passes through as is.
+
+By default a URL is judged by its host. With --urls, URLs are also checked
+against URL scope rules (scheme, port and path), so https://example.com/admin
+can be rejected while https://example.com/api passes.
+
+Exits with status 0 when every line was accepted, 1 when any line was
+rejected and 2 when the scope rules could not be loaded.`,
+		Example: `  # Keep in-scope URLs
+  cat urls.txt | bbrf company scope filter - -c acme
+
+  # Filter several files, saving rejected lines
+  bbrf company scope filter subs.txt hosts.txt --rejected out.txt -c acme
+
+  # Show why lines were rejected on stderr
+  httpx -l subs.txt | bbrf company scope filter --reasons -c acme
+
+  # Apply URL scope rules to URLs, not just their hosts
+  katana -u https://example.com | bbrf company scope filter --urls -c acme
+
+  # Filter without contacting the server
+  bbrf company scope filter subs.txt --offline -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			rejectedPath, _ := cmd.Flags().GetString("rejected")
+			reasons, _ := cmd.Flags().GetBool("reasons")
+			offline, _ := cmd.Flags().GetBool("offline")
+			urlAware, _ := cmd.Flags().GetBool("urls")
+
+			scopeManager := NewScopeManager(company)
+			var err error
+			if offline {
+				err = scopeManager.LoadCachedScope()
+			} else {
+				err = scopeManager.LoadScope()
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, errorC("❌ Failed to load scope rules: "+err.Error()))
+				os.Exit(filterFailed)
+			}
+
+			input, closeInput, err := openFilterInput(args)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, errorC("❌ "+err.Error()))
+				os.Exit(filterFailed)
+			}
+			defer closeInput()
+
+			var rejected io.Writer = io.Discard
+			if rejectedPath != "" {
+				file, err := os.Create(rejectedPath)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, errorC("❌ Failed to create rejected file: "+err.Error()))
+					os.Exit(filterFailed)
+				}
+				defer file.Close()
+				rejected = file
+			}
+
+			var reasonOut io.Writer = io.Discard
+			if reasons {
+				reasonOut = os.Stderr
+			}
+
+			rejectedCount, err := filterLines(scopeManager, urlAware, input, os.Stdout, rejected, reasonOut)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, errorC("❌ Failed to filter input: "+err.Error()))
+				os.Exit(filterFailed)
+			}
+			if rejectedCount > 0 {
+				os.Exit(filterSomeRejected)
+			}
+		},
+	}
+	filterCmd.Flags().String("rejected", "", "Write rejected lines to this file")
+	filterCmd.Flags().Bool("reasons", false, "Print each rejected line with the reason on stderr")
+	filterCmd.Flags().Bool("offline", false, "Use the cached scope only, however old")
+	filterCmd.Flags().Bool("urls", false, "Check URLs against URL scope rules, not just their host")
+
+	return filterCmd
+}
+
+// openFilterInput concatenates the file arguments, "-" or no arguments
+// meaning stdin
+func openFilterInput(args []string) (*bufio.Reader, func(), error) {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	var readers []io.Reader
+	var files []*os.File
+	closeAll := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+	for _, arg := range args {
+		if arg == "-" {
+			readers = append(readers, os.Stdin)
+			continue
+		}
+		file, err := os.Open(strings.TrimPrefix(arg, "@"))
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to open input: %w", err)
+		}
+		files = append(files, file)
+		readers = append(readers, file)
+	}
+	return bufio.NewReader(io.MultiReader(readers...)), closeAll, nil
+}
+
+// filterLines copies accepted lines to out and rejected lines to rejected,
+// writing the reason of each rejection to reasons, and returns the number of
+// rejected lines. Blank lines and # comments are dropped. Output is flushed
+// whenever the input has no more buffered data, so the filter streams.
+func filterLines(sm *ScopeManager, urlAware bool, in *bufio.Reader, out, rejected, reasons io.Writer) (int, error) {
+	accepted := bufio.NewWriter(out)
+	defer accepted.Flush()
+	rejectedBuf := bufio.NewWriter(rejected)
+	defer rejectedBuf.Flush()
+
+	rejectedCount := 0
+	for {
+		line, err := in.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			decision := decideFilterLine(sm, trimmed, urlAware)
+			if decision.Accepted {
+				fmt.Fprintln(accepted, line)
+			} else {
+				rejectedCount++
+				fmt.Fprintln(rejectedBuf, line)
+				fmt.Fprintf(reasons, "%s %s - %s\n", errorC("❌ REJECTED:"), line, decision.Detail())
+			}
+		}
+
+		if err == io.EOF {
+			return rejectedCount, nil
+		}
+		if err != nil {
+			return rejectedCount, err
+		}
+		if in.Buffered() == 0 {
+			if err := accepted.Flush(); err != nil {
+				return rejectedCount, err
+			}
+			rejectedBuf.Flush()
+		}
+	}
+}
+
+// decideFilterLine decides a line of tool output by the host in its first
+// field, or in URL-aware mode by the whole URL if the field is one
+func decideFilterLine(sm *ScopeManager, line string, urlAware bool) ScopeDecision {
+	if field := strings.Fields(line)[0]; urlAware && isURLRule(field) {
+		return sm.ShouldAcceptURL(field)
+	}
+	item, ok := filterLineHost(line)
+	if !ok {
+		return newScopeDecision(line, RuleInvalid, "no domain, IP or URL found")
+	}
+	return sm.Decide(item)
+}
+
+// filterLineHost extracts the domain, IP or ASN of a domain, URL, host:port
+// or IP line
+func filterLineHost(line string) (string, bool) {
+	field := strings.Fields(line)[0]
+	if _, err := netip.ParseAddr(strings.Trim(field, "[]")); err == nil {
+		return strings.Trim(field, "[]"), true
+	}
+	if isASNRule(field) {
+		return field, true
+	}
+	host, _ := hostFromTarget(field)
+	return host, host != ""
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilterLines(t *testing.T) {
+	sm := NewScopeManager("test")
+	sm.setRules([]string{"*.example.com", "10.0.0.0/24"}, []string{"admin.example.com"})
+
+	input := strings.Join([]string{
+		"# subfinder output",
+		"www.example.com",
+		"",
+		"https://api.example.com/login [200] [Login]",
+		"admin.example.com:443",
+		"other.net",
+		"10.0.0.5:8080",
+		"[10.0.0.6]",
+		"10.0.1.1",
+		"  ",
+		"://",
+	}, "\n")
+
+	var out, rejected, reasons bytes.Buffer
+	count, err := filterLines(sm, false, bufio.NewReader(strings.NewReader(input)), &out, &rejected, &reasons)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantOut := "www.example.com\nhttps://api.example.com/login [200] [Login]\n10.0.0.5:8080\n[10.0.0.6]\n"
+	if out.String() != wantOut {
+		t.Errorf("accepted %q, want %q", out.String(), wantOut)
+	}
+	wantRejected := "admin.example.com:443\nother.net\n10.0.1.1\n://\n"
+	if rejected.String() != wantRejected {
+		t.Errorf("rejected %q, want %q", rejected.String(), wantRejected)
+	}
+	if count != 4 {
+		t.Errorf("rejected count %d, want 4", count)
+	}
+	if lines := strings.Count(reasons.String(), "\n"); lines != 4 {
+		t.Errorf("%d reasons written, want one per rejected line:\n%s", lines, reasons.String())
+	}
+
+	// URL-aware mode also applies the path of URL rules
+	sm.setRules([]string{"https://example.com/api/*"}, nil)
+	input = "https://example.com/api/users\nhttps://example.com/admin\nexample.com\n"
+	for _, tt := range []struct {
+		urlAware bool
+		want     string
+	}{
+		{false, input},
+		{true, "https://example.com/api/users\nexample.com\n"},
+	} {
+		out.Reset()
+		if _, err := filterLines(sm, tt.urlAware, bufio.NewReader(strings.NewReader(input)), &out, io.Discard, io.Discard); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("urls=%v: accepted %q, want %q", tt.urlAware, out.String(), tt.want)
+		}
+	}
+}
+
+// TestScopeFilterExitStatus runs scope filter in a child process, since it
+// reports through its exit status
+func TestScopeFilterExitStatus(t *testing.T) {
+	if api := os.Getenv("BBRF_FILTER_TEST_API"); api != "" {
+		config = Config{API: api, Token: "test"}
+		configPath = filepath.Join(os.Getenv("BBRF_FILTER_TEST_DIR"), "config.json")
+		company = "acme"
+		cmd := createScopeFilterCommand()
+		cmd.SetArgs([]string{})
+		cmd.Execute()
+		os.Exit(filterAllAccepted)
+	}
+
+	scope := httptest.NewServer(&scopeTestServer{
+		lists: map[string][]string{"in": {"*.example.com"}, "out": {}},
+	})
+	defer scope.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	tests := []struct {
+		name, api, input string
+		want             int
+	}{
+		{"all accepted", scope.URL, "www.example.com\napi.example.com\n", filterAllAccepted},
+		{"some rejected", scope.URL, "www.example.com\nother.net\n", filterSomeRejected},
+		{"scope not loaded", broken.URL, "www.example.com\n", filterFailed},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestScopeFilterExitStatus$")
+		cmd.Env = append(os.Environ(), "BBRF_FILTER_TEST_API="+tt.api, "BBRF_FILTER_TEST_DIR="+t.TempDir())
+		cmd.Stdin = strings.NewReader(tt.input)
+		err := cmd.Run()
+
+		status := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			status = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if status != tt.want {
+			t.Errorf("%s: exit status %d, want %d", tt.name, status, tt.want)
+		}
+	}
+}
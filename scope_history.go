@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// scopeSourceServer marks snapshots of changes seen when loading the scope,
+// made outside this client
+const scopeSourceServer = "server"
+
+// scopeSnapshot is the scope of a company at one point in time
+type scopeSnapshot struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	InScope  []string  `json:"inscope"`
+	OutScope []string  `json:"outscope"`
+}
+
+// scopeHistoryPath returns the history file of a company, one JSON snapshot
+// per line
+func scopeHistoryPath(company string) string {
+	return filepath.Join(filepath.Dir(configPath), "history", "scope", serverProfile(), url.PathEscape(company)+".jsonl")
+}
+
+// readScopeHistory reads the snapshots of a company, oldest first
+func readScopeHistory(company string) ([]scopeSnapshot, error) {
+	file, err := os.Open(scopeHistoryPath(company))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var history []scopeSnapshot
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var snapshot scopeSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			return nil, fmt.Errorf("corrupt history entry: %w", err)
+		}
+		history = append(history, snapshot)
+	}
+	return history, scanner.Err()
+}
+
+// recordScopeSnapshot appends the scope to the history of a company if it
+// differs from the last snapshot
+func recordScopeSnapshot(company string, inscope, outscope []string, source string) {
+	snapshot := scopeSnapshot{
+		Time:     time.Now(),
+		Source:   source,
+		InScope:  cleanScopePatterns(inscope),
+		OutScope: cleanScopePatterns(outscope),
+	}
+
+	history, err := readScopeHistory(company)
+	if err == nil && len(history) > 0 && len(diffScopeSnapshots(history[len(history)-1], snapshot)) == 0 {
+		return
+	}
+
+	if err == nil {
+		err = appendScopeSnapshot(company, snapshot)
+	}
+	if err != nil && verboseScope {
+		fmt.Printf("%s Scope history not updated: %s\n", warning("⚠️"), err.Error())
+	}
+}
+
+// appendScopeSnapshot writes one snapshot to the end of the history file
+func appendScopeSnapshot(company string, snapshot scopeSnapshot) error {
+	path := scopeHistoryPath(company)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// scopeChanged refreshes the cache after the scope subcommands modified the
+// rules, recording the new rules in the history under source
+func scopeChanged(company, source string) {
+	sm := NewScopeManager(company)
+	if err := sm.fetchScope(nil, source); err != nil {
+		invalidateScopeCache(company)
+	}
+}
+
+// diffScopeSnapshots returns the patterns added and removed between two
+// snapshots, per list
+func diffScopeSnapshots(from, to scopeSnapshot) []ScopeChange {
+	var changes []ScopeChange
+	for _, list := range []struct {
+		name     string
+		from, to []string
+	}{{"in", from.InScope, to.InScope}, {"out", from.OutScope, to.OutScope}} {
+		before := make(map[string]bool)
+		for _, pattern := range list.from {
+			before[scopePatternKey(pattern)] = true
+		}
+		after := make(map[string]bool)
+		for _, pattern := range list.to {
+			key := scopePatternKey(pattern)
+			if !before[key] && !after[key] {
+				changes = append(changes, ScopeChange{List: list.name, Pattern: pattern, Add: true})
+			}
+			after[key] = true
+		}
+		for _, pattern := range list.from {
+			key := scopePatternKey(pattern)
+			if !after[key] {
+				changes = append(changes, ScopeChange{List: list.name, Pattern: pattern})
+				after[key] = true
+			}
+		}
+	}
+	return changes
+}
+
+// resolveScopeSnapshot finds the snapshot a reference points to: a snapshot
+// number as shown by scope history (3 or #3), "latest", a time, or a
+// duration ago (36h, 7d). Times resolve to the last snapshot taken at or
+// before them.
+func resolveScopeSnapshot(history []scopeSnapshot, ref string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "latest" || ref == "now" {
+		return len(history) - 1, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil {
+		if n < 1 || n > len(history) {
+			return 0, fmt.Errorf("no snapshot #%d, there are %d", n, len(history))
+		}
+		return n - 1, nil
+	}
+
+	at, err := parseTimeRef(ref)
+	if err != nil {
+		return 0, fmt.Errorf("can't read %q as a snapshot number, time (2006-01-02 15:04) or duration (36h, 7d)", ref)
+	}
+	index := sort.Search(len(history), func(i int) bool { return history[i].Time.After(at) }) - 1
+	if index < 0 {
+		return 0, fmt.Errorf("no snapshot at or before %s, the first is from %s",
+			at.Format("2006-01-02 15:04:05"), history[0].Time.Local().Format("2006-01-02 15:04:05"))
+	}
+	return index, nil
+}
+
+// printScopeChanges prints changes as +/- lines
+func printScopeChanges(changes []ScopeChange) {
+	listName := map[string]string{"in": "inscope ", "out": "outscope"}
+	for _, change := range changes {
+		if change.Add {
+			fmt.Printf("    %s %s %s\n", success("+"), info(listName[change.List]), domainClr(change.Pattern))
+		} else {
+			fmt.Printf("    %s %s %s\n", errorC("-"), info(listName[change.List]), domainClr(change.Pattern))
+		}
+	}
+}
+
+// createScopeHistoryCommand builds "scope history" and "scope history diff"
+func createScopeHistoryCommand() *cobra.Command {
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "📜 Show how the scope changed over time",
+		Long: `Every time the scope is loaded from the server or changed with the scope
+subcommands, a snapshot is kept under ~/.bbrf/history/scope/ if the rules
+differ from the previous one. Changes made outside bbrf show up with source
+"server" the next time the scope is loaded.`,
+		Example: `  # List the snapshots with what was added and removed
+  bbrf company scope history -c acme
+
+  # Compare the scope of a week ago with today
+  bbrf company scope history diff 7d latest -c acme
+
+  # Compare two snapshots or points in time
+  bbrf company scope history diff 2 5 -c acme
+  bbrf company scope history diff "2025-01-01" "2025-02-01 12:00" -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+
+			history, err := readScopeHistory(company)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to read scope history: " + err.Error()))
+				os.Exit(1)
+			}
+
+			if jsonOutput {
+				if history == nil {
+					history = []scopeSnapshot{}
+				}
+				output, _ := json.MarshalIndent(history, "", "  ")
+				fmt.Println(string(output))
+				return
+			}
+
+			if len(history) == 0 {
+				fmt.Println(warning("⚠️ No scope history yet, it starts the next time the scope is loaded"))
+				return
+			}
+
+			fmt.Println(info(fmt.Sprintf("📜 Scope history for %s: %d snapshots", company, len(history))))
+			for i, snapshot := range history {
+				fmt.Printf("\n%s %s  %s  %s\n", title(fmt.Sprintf("#%d", i+1)),
+					snapshot.Time.Local().Format("2006-01-02 15:04:05"), data(snapshot.Source),
+					info(fmt.Sprintf("%d in, %d out", len(snapshot.InScope), len(snapshot.OutScope))))
+				if i == 0 {
+					fmt.Println("    first snapshot")
+					continue
+				}
+				printScopeChanges(diffScopeSnapshots(history[i-1], snapshot))
+			}
+		},
+	}
+	historyCmd.Flags().Bool("json", false, "Print the snapshots as JSON")
+
+	historyCmd.AddCommand(&cobra.Command{
+		Use:   "diff <t1> <t2>",
+		Short: "🔀 Show patterns added and removed between two points in time",
+		Long: `Compare the scope at two points. Each may be a snapshot number from scope
+history (3 or #3), "latest", a local time (2006-01-02, 2006-01-02 15:04 or
+RFC 3339) or a duration ago (36h, 7d); times use the last snapshot taken at
+or before them.`,
+		Example: `  # What changed in the last 30 days
+  bbrf company scope history diff 30d latest -c acme
+
+  # Between two snapshots
+  bbrf company scope history diff 1 4 -c acme`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			history, err := readScopeHistory(company)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to read scope history: " + err.Error()))
+				os.Exit(1)
+			}
+			if len(history) == 0 {
+				fmt.Println(warning("⚠️ No scope history yet, it starts the next time the scope is loaded"))
+				return
+			}
+
+			var indexes [2]int
+			for i, ref := range args {
+				if indexes[i], err = resolveScopeSnapshot(history, ref); err != nil {
+					fmt.Println(errorC("❌ " + err.Error()))
+					os.Exit(1)
+				}
+			}
+			from, to := history[indexes[0]], history[indexes[1]]
+
+			fmt.Println(info(fmt.Sprintf("📜 Scope changes for %s", company)))
+			fmt.Printf("   from #%d %s (%s)\n", indexes[0]+1, from.Time.Local().Format("2006-01-02 15:04:05"), from.Source)
+			fmt.Printf("   to   #%d %s (%s)\n\n", indexes[1]+1, to.Time.Local().Format("2006-01-02 15:04:05"), to.Source)
+
+			changes := diffScopeSnapshots(from, to)
+			if len(changes) == 0 {
+				fmt.Println(success("✅ No changes"))
+				return
+			}
+			printScopeChanges(changes)
+
+			added := 0
+			for _, change := range changes {
+				if change.Add {
+					added++
+				}
+			}
+			fmt.Println(count(fmt.Sprintf("\n📊 %d added, %d removed", added, len(changes)-added)))
+		},
+	})
+
+	return historyCmd
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffScopeSnapshots(t *testing.T) {
+	from := scopeSnapshot{
+		InScope:  []string{"*.example.com", "old.example.org", "API.example.net"},
+		OutScope: []string{"admin.example.com"},
+	}
+	to := scopeSnapshot{
+		InScope:  []string{"*.Example.com", "api.example.net", "new.example.org", "new.example.org"},
+		OutScope: []string{"admin.example.com", "vpn.example.com"},
+	}
+
+	want := []ScopeChange{
+		{List: "in", Pattern: "new.example.org", Add: true},
+		{List: "in", Pattern: "old.example.org"},
+		{List: "out", Pattern: "vpn.example.com", Add: true},
+	}
+	if got := diffScopeSnapshots(from, to); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := diffScopeSnapshots(to, to); len(got) != 0 {
+		t.Errorf("snapshot differs from itself: %v", got)
+	}
+}
+
+func TestResolveScopeSnapshot(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.Local) }
+	history := []scopeSnapshot{{Time: day(1)}, {Time: day(5)}, {Time: day(10)}}
+
+	tests := []struct {
+		ref  string
+		want int
+		ok   bool
+	}{
+		{"1", 0, true},
+		{"#3", 2, true},
+		{"latest", 2, true},
+		{"now", 2, true},
+		{"2026-10-05 12:00", 1, true},
+		{"2026-10-07", 1, true},
+		{"2026-10-10T11:59", 1, true},
+		{"1h", 2, true},
+		{"0", 0, false},
+		{"#4", 0, false},
+		{"2026-09-30", 0, false},
+		{"last week", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := resolveScopeSnapshot(history, tt.ref)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("resolveScopeSnapshot(%q) = %d, %v, want %d, ok %v", tt.ref, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRecordScopeSnapshot(t *testing.T) {
+	oldConfig, oldPath := config, configPath
+	defer func() { config, configPath = oldConfig, oldPath }()
+	config = Config{API: "https://bbrf.example.com"}
+	configPath = filepath.Join(t.TempDir(), "config.json")
+
+	recordScopeSnapshot("acme", []string{"*.example.com"}, nil, scopeSourceServer)
+	// The same rules in another order or case are not a new snapshot
+	recordScopeSnapshot("acme", []string{" *.Example.com"}, []string{}, "apply")
+	recordScopeSnapshot("acme", []string{"*.example.com", "example.org"}, nil, "apply")
+
+	history, err := readScopeHistory("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("%d snapshots, want 2", len(history))
+	}
+	if history[1].Source != "apply" || !reflect.DeepEqual(history[1].InScope, []string{"*.example.com", "example.org"}) {
+		t.Errorf("second snapshot %+v", history[1])
+	}
+
+	// Other companies have their own history
+	if other, err := readScopeHistory("globex"); err != nil || len(other) != 0 {
+		t.Errorf("globex history %v, %v, want none", other, err)
+	}
+}
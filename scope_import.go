@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ImportedAsset is one scope entry read from a bug bounty platform export
+type ImportedAsset struct {
+	Identifier string
+	AssetType  string
+	InScope    bool
+}
+
+// ImportedRule is an asset mapped to a BBRF scope pattern. Pattern is empty
+// when the asset type has no scope rule equivalent.
+type ImportedRule struct {
+	Asset   ImportedAsset
+	Pattern string
+	Note    string
+}
+
+// scopeImportFormats are the parsers for scope import --format
+var scopeImportFormats = map[string]func([]byte) ([]ImportedAsset, error){
+	"h1":        parseHackerOneScope,
+	"bugcrowd":  parseBugcrowdScope,
+	"intigriti": parseIntigritiScope,
+	"yeswehack": parseYesWeHackScope,
+}
+
+// parseHackerOneScope reads the structured scope CSV from the program page, or
+// the structured_scopes JSON of the API ({"data":[{"attributes":{...}}]})
+func parseHackerOneScope(content []byte) ([]ImportedAsset, error) {
+	type h1Scope struct {
+		AssetIdentifier       string `json:"asset_identifier"`
+		AssetType             string `json:"asset_type"`
+		EligibleForSubmission *bool  `json:"eligible_for_submission"`
+	}
+	toAsset := func(s h1Scope) ImportedAsset {
+		return ImportedAsset{
+			Identifier: s.AssetIdentifier,
+			AssetType:  s.AssetType,
+			InScope:    s.EligibleForSubmission == nil || *s.EligibleForSubmission,
+		}
+	}
+
+	if looksLikeJSON(content) {
+		var wrapped struct {
+			Data []struct {
+				Attributes h1Scope `json:"attributes"`
+			} `json:"data"`
+		}
+		var assets []ImportedAsset
+		if err := json.Unmarshal(content, &wrapped); err == nil && len(wrapped.Data) > 0 {
+			for _, d := range wrapped.Data {
+				assets = append(assets, toAsset(d.Attributes))
+			}
+			return assets, nil
+		}
+
+		var plain []h1Scope
+		if err := json.Unmarshal(content, &plain); err != nil {
+			return nil, fmt.Errorf("unrecognized HackerOne JSON: %w", err)
+		}
+		for _, s := range plain {
+			assets = append(assets, toAsset(s))
+		}
+		return assets, nil
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid HackerOne CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	idCol, ok := columns["identifier"]
+	if !ok {
+		return nil, fmt.Errorf("HackerOne CSV has no identifier column")
+	}
+	typeCol, hasType := columns["asset_type"]
+	eligibleCol, hasEligible := columns["eligible_for_submission"]
+
+	var assets []ImportedAsset
+	for _, record := range records[1:] {
+		asset := ImportedAsset{Identifier: record[idCol], InScope: true}
+		if hasType && typeCol < len(record) {
+			asset.AssetType = record[typeCol]
+		}
+		if hasEligible && eligibleCol < len(record) {
+			if eligible, err := strconv.ParseBool(strings.TrimSpace(record[eligibleCol])); err == nil {
+				asset.InScope = eligible
+			}
+		}
+		assets = append(assets, asset)
+	}
+	return assets, nil
+}
+
+// parseBugcrowdScope reads a Bugcrowd target group export
+// ({"targets":{"in_scope":[...],"out_of_scope":[...]}}) or a plain target list
+// with one in-scope target per line
+func parseBugcrowdScope(content []byte) ([]ImportedAsset, error) {
+	if !looksLikeJSON(content) {
+		return parsePlainTargetList(content), nil
+	}
+	return parseTargetsJSON(content, "target", "type")
+}
+
+// parseIntigritiScope reads an Intigriti program export, either with
+// in_scope/out_of_scope target lists or the API's domains list where the tier
+// marks out-of-scope entries
+func parseIntigritiScope(content []byte) ([]ImportedAsset, error) {
+	var api struct {
+		Domains []struct {
+			Endpoint string          `json:"endpoint"`
+			Type     json.RawMessage `json:"type"`
+			Tier     json.RawMessage `json:"tier"`
+		} `json:"domains"`
+	}
+	if err := json.Unmarshal(content, &api); err == nil && len(api.Domains) > 0 {
+		var assets []ImportedAsset
+		for _, d := range api.Domains {
+			tier := jsonValueString(d.Tier)
+			assets = append(assets, ImportedAsset{
+				Identifier: d.Endpoint,
+				AssetType:  jsonValueString(d.Type),
+				InScope:    !strings.EqualFold(tier, "Out Of Scope"),
+			})
+		}
+		return assets, nil
+	}
+	return parseTargetsJSON(content, "endpoint", "type")
+}
+
+// parseYesWeHackScope reads a YesWeHack program export, either with
+// in_scope/out_of_scope target lists or the API's scopes and out_of_scope lists
+func parseYesWeHackScope(content []byte) ([]ImportedAsset, error) {
+	var api struct {
+		Scopes []struct {
+			Scope     string `json:"scope"`
+			ScopeType string `json:"scope_type"`
+		} `json:"scopes"`
+		OutOfScope []string `json:"out_of_scope"`
+	}
+	if err := json.Unmarshal(content, &api); err == nil && len(api.Scopes) > 0 {
+		var assets []ImportedAsset
+		for _, s := range api.Scopes {
+			assets = append(assets, ImportedAsset{Identifier: s.Scope, AssetType: s.ScopeType, InScope: true})
+		}
+		for _, s := range api.OutOfScope {
+			assets = append(assets, ImportedAsset{Identifier: s, InScope: false})
+		}
+		return assets, nil
+	}
+	return parseTargetsJSON(content, "target", "type")
+}
+
+// parseTargetsJSON reads the {"targets":{"in_scope":[...],"out_of_scope":[...]}}
+// layout shared by several platform exports. Each entry is an object with the
+// identifier and type under the given keys.
+func parseTargetsJSON(content []byte, idKey, typeKey string) ([]ImportedAsset, error) {
+	var export struct {
+		Targets struct {
+			InScope    []map[string]json.RawMessage `json:"in_scope"`
+			OutOfScope []map[string]json.RawMessage `json:"out_of_scope"`
+		} `json:"targets"`
+	}
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, fmt.Errorf("unrecognized export: %w", err)
+	}
+
+	var assets []ImportedAsset
+	add := func(entries []map[string]json.RawMessage, inScope bool) {
+		for _, entry := range entries {
+			id := jsonValueString(entry[idKey])
+			if id == "" {
+				// Some exports use name/uri instead
+				id = jsonValueString(entry["uri"])
+			}
+			assets = append(assets, ImportedAsset{Identifier: id, AssetType: jsonValueString(entry[typeKey]), InScope: inScope})
+		}
+	}
+	add(export.Targets.InScope, true)
+	add(export.Targets.OutOfScope, false)
+	return assets, nil
+}
+
+func parsePlainTargetList(content []byte) []ImportedAsset {
+	var assets []ImportedAsset
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		assets = append(assets, ImportedAsset{Identifier: line, InScope: true})
+	}
+	return assets
+}
+
+// jsonValueString reads a JSON string, or the "value" field of an object such
+// as Intigriti's {"id":1,"value":"Url"}
+func jsonValueString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var obj struct {
+		Value string `json:"value"`
+	}
+	json.Unmarshal(raw, &obj)
+	return obj.Value
+}
+
+func looksLikeJSON(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// mapImportedAssets turns platform assets into scope rules. An identifier may
+// list several assets separated by commas.
+func mapImportedAssets(assets []ImportedAsset) []ImportedRule {
+	var rules []ImportedRule
+	for _, asset := range assets {
+		for _, identifier := range strings.Split(asset.Identifier, ",") {
+			identifier = strings.TrimSpace(identifier)
+			if identifier == "" {
+				continue
+			}
+			one := asset
+			one.Identifier = identifier
+			pattern, note := scopeRuleForAsset(identifier, asset.AssetType)
+			rules = append(rules, ImportedRule{Asset: one, Pattern: pattern, Note: note})
+		}
+	}
+	return rules
+}
+
+// scopeRuleForAsset maps one platform asset to a scope pattern:
+//
+//	wildcard *.example.com            -> *.example.com
+//	URL https://app.example.com/x     -> =app.example.com (that host only)
+//	domain example.com                -> example.com
+//	CIDR / IP / IP range              -> as is
+//	ASN 12345                         -> AS12345
+//
+// Mobile apps, source code and other non-network assets have no equivalent.
+func scopeRuleForAsset(identifier, assetType string) (string, string) {
+	kind := strings.ToLower(strings.NewReplacer("_", "", "-", "", " ", "").Replace(assetType))
+
+	switch kind {
+	case "asn":
+		if asn, ok := parseASN(identifier); ok {
+			return fmt.Sprintf("AS%d", asn), "asn"
+		}
+		return "", "invalid ASN"
+	case "cidr", "ipaddress", "iprange", "network":
+		rule := strings.ReplaceAll(identifier, " ", "")
+		if _, ok := parseIPRule(rule); ok {
+			return rule, "network"
+		}
+		return "", "invalid IP, CIDR or range"
+	case "", "url", "wildcard", "domain", "website", "webapplication", "api", "other":
+		// Decided by the identifier below
+	default:
+		return "", "unsupported asset type " + assetType
+	}
+
+	if asn, ok := parseASNRule(identifier); ok {
+		return fmt.Sprintf("AS%d", asn), "asn"
+	}
+	if rule := strings.ReplaceAll(identifier, " ", ""); isIPRule(rule) {
+		return rule, "network"
+	}
+
+	host, isURL := hostFromTarget(identifier)
+	if host == "" || strings.ContainsAny(host, " /") || !strings.Contains(host, ".") {
+		return "", "not a host, URL or network"
+	}
+
+	switch {
+	case strings.Contains(host, "*"):
+		return host, "wildcard"
+	case kind == "wildcard":
+		return "*." + host, "wildcard"
+	case isURL || kind == "url":
+		return "=" + host, "single host"
+	default:
+		return host, "domain"
+	}
+}
+
+// hostFromTarget extracts the host of a URL or bare host target, and reports
+// whether it was written as a URL
+func hostFromTarget(target string) (string, bool) {
+	target = strings.TrimSpace(target)
+	isURL := strings.Contains(target, "://")
+	if !isURL {
+		target = "scheme://" + target
+	}
+
+	// url.Parse rejects * in hosts, so swap it out while parsing
+	parsed, err := url.Parse(strings.ReplaceAll(target, "*", "wildcard-placeholder"))
+	if err != nil {
+		return "", isURL
+	}
+	host := strings.ReplaceAll(parsed.Hostname(), "wildcard-placeholder", "*")
+	return strings.ToLower(strings.TrimSuffix(host, ".")), isURL || parsed.Path != "" && parsed.Path != "/"
+}
+
+// readImportFile reads a file argument, "-" meaning stdin
+func readImportFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(strings.TrimPrefix(path, "@"))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopeImportFormats(t *testing.T) {
+	tests := []struct {
+		name, format, content string
+		want                  []ImportedAsset
+	}{
+		{
+			name:   "h1 csv",
+			format: "h1",
+			content: "identifier,asset_type,instruction,eligible_for_submission\n" +
+				"*.example.com,WILDCARD,,true\n" +
+				"\"legacy.example.com,old.example.com\",URL,,false\n" +
+				"com.example.app,GOOGLE_PLAY_APP_ID,,true\n",
+			want: []ImportedAsset{
+				{"*.example.com", "WILDCARD", true},
+				{"legacy.example.com,old.example.com", "URL", false},
+				{"com.example.app", "GOOGLE_PLAY_APP_ID", true},
+			},
+		},
+		{
+			name:   "h1 api json",
+			format: "h1",
+			content: `{"data":[
+				{"attributes":{"asset_identifier":"*.example.com","asset_type":"WILDCARD","eligible_for_submission":true}},
+				{"attributes":{"asset_identifier":"10.0.0.0/24","asset_type":"CIDR","eligible_for_submission":false}}]}`,
+			want: []ImportedAsset{
+				{"*.example.com", "WILDCARD", true},
+				{"10.0.0.0/24", "CIDR", false},
+			},
+		},
+		{
+			name:    "h1 json list without eligibility",
+			format:  "h1",
+			content: `[{"asset_identifier":"api.example.com","asset_type":"URL"}]`,
+			want:    []ImportedAsset{{"api.example.com", "URL", true}},
+		},
+		{
+			name:    "bugcrowd targets",
+			format:  "bugcrowd",
+			content: `{"targets":{"in_scope":[{"target":"*.example.com","type":"website"}],"out_of_scope":[{"uri":"blog.example.com"}]}}`,
+			want: []ImportedAsset{
+				{"*.example.com", "website", true},
+				{"blog.example.com", "", false},
+			},
+		},
+		{
+			name:    "bugcrowd plain list",
+			format:  "bugcrowd",
+			content: "# program targets\n*.example.com\n\n  api.example.org  \n",
+			want: []ImportedAsset{
+				{"*.example.com", "", true},
+				{"api.example.org", "", true},
+			},
+		},
+		{
+			name:   "intigriti api",
+			format: "intigriti",
+			content: `{"domains":[
+				{"endpoint":"*.example.com","type":{"id":7,"value":"Wildcard"},"tier":{"id":1,"value":"Tier 1"}},
+				{"endpoint":"status.example.com","type":{"id":1,"value":"Url"},"tier":{"id":5,"value":"Out Of Scope"}}]}`,
+			want: []ImportedAsset{
+				{"*.example.com", "Wildcard", true},
+				{"status.example.com", "Url", false},
+			},
+		},
+		{
+			name:    "intigriti targets",
+			format:  "intigriti",
+			content: `{"targets":{"in_scope":[{"endpoint":"app.example.com","type":"url"}]}}`,
+			want:    []ImportedAsset{{"app.example.com", "url", true}},
+		},
+		{
+			name:   "yeswehack api",
+			format: "yeswehack",
+			content: `{"scopes":[{"scope":"*.example.com","scope_type":"web-application"}],
+				"out_of_scope":["mail.example.com"]}`,
+			want: []ImportedAsset{
+				{"*.example.com", "web-application", true},
+				{"mail.example.com", "", false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := scopeImportFormats[tt.format]([]byte(tt.content))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := parseHackerOneScope([]byte("asset,type\nexample.com,URL\n")); err == nil {
+		t.Error("h1 csv without identifier column: no error")
+	}
+	if _, err := parseBugcrowdScope([]byte(`{"targets": [1, 2]}`)); err == nil {
+		t.Error("bugcrowd json in an unknown layout: no error")
+	}
+}
+
+func TestMapImportedAssets(t *testing.T) {
+	tests := []struct {
+		identifier, assetType string
+		patterns              []string
+	}{
+		{"*.example.com", "WILDCARD", []string{"*.example.com"}},
+		{"example.com", "wildcard", []string{"*.example.com"}},
+		{"https://App.Example.com/login", "URL", []string{"=app.example.com"}},
+		{"app.example.com/login", "", []string{"=app.example.com"}},
+		{"api.example.com", "domain", []string{"api.example.com"}},
+		{"a.example.com, b.example.com", "URL", []string{"=a.example.com", "=b.example.com"}},
+		{"10.0.0.0/24", "CIDR", []string{"10.0.0.0/24"}},
+		{"10.0.0.1 - 10.0.0.9", "IP_RANGE", []string{"10.0.0.1-10.0.0.9"}},
+		{"10.0.0.0/24", "", []string{"10.0.0.0/24"}},
+		{"13335", "ASN", []string{"AS13335"}},
+		{"AS13335", "other", []string{"AS13335"}},
+		{"bogus", "CIDR", []string{""}},
+		{"com.example.app", "GOOGLE_PLAY_APP_ID", []string{""}},
+		{"localhost", "URL", []string{""}},
+	}
+
+	for _, tt := range tests {
+		var patterns []string
+		for _, rule := range mapImportedAssets([]ImportedAsset{{tt.identifier, tt.assetType, true}}) {
+			patterns = append(patterns, rule.Pattern)
+			if rule.Pattern == "" && rule.Note == "" {
+				t.Errorf("%q (%s) is skipped without a note", rule.Asset.Identifier, tt.assetType)
+			}
+		}
+		if !reflect.DeepEqual(patterns, tt.patterns) {
+			t.Errorf("%q (%s): got %q, want %q", tt.identifier, tt.assetType, patterns, tt.patterns)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LintKind is the category of a problem found by scope lint
+type LintKind string
+
+const (
+	LintDuplicate    LintKind = "duplicate"
+	LintSubsumed     LintKind = "subsumed"
+	LintNegated      LintKind = "negated"
+	LintMalformed    LintKind = "malformed"
+	LintPublicSuffix LintKind = "public-suffix"
+)
+
+// LintIssue is a single problem with a scope pattern
+type LintIssue struct {
+	Kind    LintKind `json:"kind"`
+	List    string   `json:"list"`
+	Pattern string   `json:"pattern"`
+	Other   string   `json:"other,omitempty"`
+	Message string   `json:"message"`
+}
+
+type lintRuleKind int
+
+const (
+	lintHost     lintRuleKind = iota // example.com, *.example.com
+	lintExact                        // =example.com
+	lintWildcard                     // api*.example.com
+	lintRegex                        // re:...
+	lintIP                           // IP, CIDR, range
+	lintASN                          // AS<number>
+	lintURL                          // https://example.com/api/*
+)
+
+// lintRule is a scope pattern reduced to what is needed to compare it with others
+type lintRule struct {
+	raw     string
+	list    string
+	negated bool
+	kind    lintRuleKind
+	// host is the host of host and exact rules, and for wildcard rules the
+	// domain every match is guaranteed to be under (empty if there is none)
+	host  string
+	ips   ipRange
+	asn   uint32
+	regex *regexp.Regexp
+}
+
+var (
+	hostPatternChars = regexp.MustCompile(`^[a-z0-9*_-]+(\.[a-z0-9*_-]+)*$`)
+	looksLikeIPRule  = regexp.MustCompile(`^[0-9a-f:.]+(/\d+|-[0-9a-f:.]+)$`)
+)
+
+// lintScope reports duplicate, subsumed, negated, malformed and public suffix
+// patterns in the in- and out-of-scope lists
+func lintScope(inScope, outScope []string) []LintIssue {
+	var issues []LintIssue
+	var rules []lintRule
+
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"in", inScope}, {"out", outScope}} {
+		seen := make(map[string]string)
+		for _, raw := range list.patterns {
+			pattern := strings.TrimSpace(raw)
+			if pattern == "" {
+				continue
+			}
+
+			key := scopePatternKey(pattern)
+			if first, ok := seen[key]; ok {
+				issues = append(issues, LintIssue{LintDuplicate, list.name, raw, first, "listed more than once"})
+				continue
+			}
+			seen[key] = raw
+
+			// Ignored when matching, so it can't cover other patterns either
+			if list.name == "in" && publicSuffixPattern(pattern) {
+				issues = append(issues, LintIssue{LintPublicSuffix, list.name, raw, "",
+					"would put every domain under a public suffix in scope, it is ignored when matching"})
+				continue
+			}
+
+			rule, problems := parseLintRule(raw, list.name)
+			for _, problem := range problems {
+				issues = append(issues, LintIssue{LintMalformed, list.name, raw, "", problem})
+			}
+			if rule != nil {
+				rules = append(rules, *rule)
+			}
+		}
+	}
+
+	// Report each pattern once per kind, against the first rule covering it
+	reported := make(map[string]bool)
+	report := func(issue LintIssue) {
+		key := string(issue.Kind) + "\x00" + issue.List + "\x00" + issue.Pattern
+		if !reported[key] {
+			reported[key] = true
+			issues = append(issues, issue)
+		}
+	}
+
+	for i, a := range rules {
+		for j, b := range rules {
+			if i == j || !lintCovers(b, a) {
+				continue
+			}
+
+			switch {
+			case a.list == b.list && a.negated == b.negated:
+				if lintCovers(a, b) {
+					// Equivalent patterns, report the later one only
+					if j < i {
+						report(LintIssue{LintDuplicate, a.list, a.raw, b.raw, "matches exactly the same names as " + b.raw})
+					}
+				} else {
+					report(LintIssue{LintSubsumed, a.list, a.raw, b.raw, "every match is already covered by " + b.raw})
+				}
+			case a.list == "in" && !a.negated && b.list == "out" && !b.negated:
+				report(LintIssue{LintNegated, a.list, a.raw, b.raw, "every match is out of scope because of " + b.raw})
+			case a.list == "in" && !a.negated && b.list == "in" && b.negated:
+				report(LintIssue{LintNegated, a.list, a.raw, b.raw, "every match is excluded by " + b.raw})
+			}
+		}
+	}
+
+	return issues
+}
+
+// parseLintRule classifies a pattern the same way compileScopeList does and
+// returns what is wrong with it. A nil rule means it can't match anything.
+func parseLintRule(raw, list string) (*lintRule, []string) {
+	var problems []string
+	rule := &lintRule{raw: raw, list: list}
+
+	pattern := strings.TrimSpace(raw)
+	if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+		rule.negated = true
+		pattern = strings.TrimSpace(rest)
+		if list == "out" {
+			problems = append(problems, "! negations have no effect in the out-of-scope list")
+		}
+	}
+
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		regex, err := compileScopeRegex(expr)
+		if err != nil {
+			return nil, append(problems, "invalid regex: "+err.Error())
+		}
+		rule.kind = lintRegex
+		rule.regex = regex
+		return rule, problems
+	}
+
+	if isURLRule(pattern) {
+		urlRule, err := parseURLRule(pattern)
+		if err != nil {
+			return nil, append(problems, "invalid URL rule: "+err.Error())
+		}
+		// Covered by host patterns matching its host, never covering anything
+		rule.kind = lintURL
+		host := strings.TrimPrefix(strings.TrimPrefix(urlRule.hostPattern, "="), "*.")
+		if !strings.Contains(host, "*") && !isIPRule(host) {
+			rule.host = host
+		}
+		return rule, problems
+	}
+
+	pattern = strings.ToLower(pattern)
+
+	if r, ok := parseIPRule(pattern); ok {
+		rule.kind = lintIP
+		rule.ips = r
+		return rule, problems
+	}
+	if looksLikeIPRule.MatchString(pattern) {
+		return nil, append(problems, "looks like a CIDR or IP range but can't be parsed")
+	}
+
+	if asn, ok := parseASNRule(pattern); ok {
+		rule.kind = lintASN
+		rule.asn = asn
+		return rule, problems
+	}
+
+	host, exact := strings.CutPrefix(pattern, "=")
+	switch {
+	case strings.Contains(host, "/"):
+		return nil, append(problems, "looks like a URL without a scheme, write URL rules as scheme://host/path")
+	case strings.ContainsAny(host, "?[]"):
+		problems = append(problems, "glob characters ? [ ] are matched literally, only * is a wildcard")
+	case strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") || strings.Contains(host, ".."):
+		problems = append(problems, "empty label (leading, trailing or double dot)")
+	case !hostPatternChars.MatchString(host):
+		problems = append(problems, "contains characters that are not valid in hostnames")
+	}
+
+	switch {
+	case !strings.Contains(host, "*"):
+		rule.kind = lintHost
+		if exact {
+			rule.kind = lintExact
+		}
+		rule.host = host
+	case strings.HasPrefix(host, "*.") && !exact:
+		rule.kind = lintHost
+		rule.host = host[2:]
+		if strings.Contains(rule.host, "*") {
+			problems = append(problems, "only the leading *. is a wildcard, later * are matched literally")
+		}
+	default:
+		regex, err := regexp.Compile(wildcardToRegex(host))
+		if err != nil {
+			// matchesWildcard would fall back to filepath.Match here
+			return nil, append(problems, "wildcard can't be converted to a regex and falls back to glob matching")
+		}
+		rule.kind = lintWildcard
+		rule.regex = regex
+		if suffix := host[strings.LastIndexByte(host, '*')+1:]; strings.HasPrefix(suffix, ".") {
+			rule.host = suffix[1:]
+		}
+	}
+
+	return rule, problems
+}
+
+// lintCovers reports whether every name matched by a is also matched by b.
+// It is conservative: false means "not provably covered".
+func lintCovers(b, a lintRule) bool {
+	switch a.kind {
+	case lintIP:
+		return b.kind == lintIP && b.ips.contains(a.ips.from) && b.ips.contains(a.ips.to)
+	case lintASN:
+		return b.kind == lintASN && b.asn == a.asn
+	case lintRegex:
+		return b.kind == lintRegex && b.raw == a.raw
+	}
+
+	switch b.kind {
+	case lintHost:
+		return a.host != "" && (a.host == b.host || strings.HasSuffix(a.host, "."+b.host))
+	case lintExact:
+		return a.kind == lintExact && a.host == b.host
+	case lintWildcard, lintRegex:
+		if a.kind == lintExact {
+			return b.regex.MatchString(a.host)
+		}
+		return a.kind == b.kind && strings.EqualFold(a.raw, b.raw)
+	}
+	return false
+}
+
+// formatLintIssue renders an issue for the terminal
+func formatLintIssue(issue LintIssue) string {
+	list := "in-scope"
+	if issue.List == "out" {
+		list = "out-of-scope"
+	}
+	return fmt.Sprintf("%s %s %s - %s", warning(fmt.Sprintf("%-13s", strings.ToUpper(string(issue.Kind)))),
+		info(fmt.Sprintf("[%s]", list)), domainClr(issue.Pattern), issue.Message)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintScope(t *testing.T) {
+	tests := []struct {
+		name              string
+		inScope, outScope []string
+		want              []LintIssue
+	}{
+		{
+			name:    "clean",
+			inScope: []string{"*.example.com", "*.example.org", "10.0.0.0/8", "AS1"},
+		},
+		{
+			name:    "duplicates",
+			inScope: []string{"*.example.com", "*.EXAMPLE.com", "example.com", "AS1", "AS1"},
+			want: []LintIssue{
+				{Kind: LintDuplicate, List: "in", Pattern: "*.EXAMPLE.com", Other: "*.example.com"},
+				{Kind: LintDuplicate, List: "in", Pattern: "AS1", Other: "AS1"},
+				{Kind: LintDuplicate, List: "in", Pattern: "example.com", Other: "*.example.com"},
+			},
+		},
+		{
+			name:     "subsumed",
+			inScope:  []string{"api.example.com", "*.example.com", "=www.example.com", "10.1.0.0/16", "10.0.0.0/8", "https://example.com/api/*"},
+			outScope: []string{"*.corp.example.com", "vpn.corp.example.com"},
+			want: []LintIssue{
+				{Kind: LintSubsumed, List: "in", Pattern: "api.example.com", Other: "*.example.com"},
+				{Kind: LintSubsumed, List: "in", Pattern: "=www.example.com", Other: "*.example.com"},
+				{Kind: LintSubsumed, List: "in", Pattern: "10.1.0.0/16", Other: "10.0.0.0/8"},
+				{Kind: LintSubsumed, List: "in", Pattern: "https://example.com/api/*", Other: "*.example.com"},
+				{Kind: LintSubsumed, List: "out", Pattern: "vpn.corp.example.com", Other: "*.corp.example.com"},
+			},
+		},
+		{
+			name:     "negated",
+			inScope:  []string{"*.corp.example.com", "x.dev.example.org", "!*.dev.example.org"},
+			outScope: []string{"*.example.com"},
+			want: []LintIssue{
+				{Kind: LintNegated, List: "in", Pattern: "*.corp.example.com", Other: "*.example.com"},
+				{Kind: LintNegated, List: "in", Pattern: "x.dev.example.org", Other: "!*.dev.example.org"},
+			},
+		},
+		{
+			name:     "malformed and public suffixes",
+			inScope:  []string{"foo..com", "10.0.0.0/33", `re:(api\.example\.com`, "co.uk", "*.com"},
+			outScope: []string{"co.uk"},
+			want: []LintIssue{
+				{Kind: LintMalformed, List: "in", Pattern: "foo..com"},
+				{Kind: LintMalformed, List: "in", Pattern: "10.0.0.0/33"},
+				{Kind: LintMalformed, List: "in", Pattern: `re:(api\.example\.com`},
+				{Kind: LintPublicSuffix, List: "in", Pattern: "co.uk"},
+				{Kind: LintPublicSuffix, List: "in", Pattern: "*.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []LintIssue
+		for _, issue := range lintScope(tt.inScope, tt.outScope) {
+			if issue.Message == "" {
+				t.Errorf("%s: %s issue for %s has no message", tt.name, issue.Kind, issue.Pattern)
+			}
+			issue.Message = ""
+			got = append(got, issue)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"net/netip"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// scopeMatcher is the compiled form of one scope list. Host patterns are
+// stored in a trie keyed by reversed labels so a domain is matched in a single
+// walk regardless of how many patterns there are; only patterns with a
+// wildcard inside the host fall back to precompiled regexes.
+type scopeMatcher struct {
+	hosts     *labelTrie
+	wildcards []wildcardRule
+	ipRules   []ipRule
+	asns      map[uint32]string
+	asnRanges []asnRange
+	urlRules  []*urlRule
+
+	// domainRules counts the host patterns, for the accept-by-default rule
+	domainRules int
+
+	// publicSuffixes holds ignored patterns that would match everything under
+	// a public suffix, like co.uk. They still count as domain rules, so a
+	// list of only such patterns rejects everything rather than accepting it.
+	publicSuffixes []string
+
+	// negations holds the !patterns of the list, compiled the same way
+	negations *scopeMatcher
+}
+
+// scopeMatch is the pattern that matched an item and how it matched
+type scopeMatch struct {
+	Pattern string
+	Type    MatchType
+}
+
+type wildcardRule struct {
+	pattern string
+	kind    MatchType
+	// suffix is the literal text after the last '*'. Domains that don't end
+	// with it are skipped without running the regex.
+	suffix string
+	regex  *regexp.Regexp
+}
+
+type ipRule struct {
+	ipRange
+	pattern string
+	kind    MatchType
+}
+
+type asnRange struct {
+	ipRange
+	pattern string
+}
+
+// labelTrie is a suffix trie over domain labels: example.com is stored as
+// com -> example. A node with a pattern matches itself and every name below
+// it, a node with an exact pattern (=host) only matches itself.
+type labelTrie struct {
+	children map[string]*labelTrie
+	pattern  string
+	wildcard bool
+	exact    string
+}
+
+func newLabelTrie() *labelTrie {
+	return &labelTrie{children: make(map[string]*labelTrie)}
+}
+
+func (t *labelTrie) insert(host, pattern string, exact, wildcard bool) {
+	node := t
+	for end := len(host); end >= 0; {
+		start := strings.LastIndexByte(host[:end], '.') + 1
+		label := host[start:end]
+		child, ok := node.children[label]
+		if !ok {
+			child = newLabelTrie()
+			node.children[label] = child
+		}
+		node = child
+		end = start - 1
+	}
+	if exact {
+		if node.exact == "" {
+			node.exact = pattern
+		}
+	} else if node.pattern == "" {
+		node.pattern = pattern
+		node.wildcard = wildcard
+	}
+}
+
+// match walks domain from its last label and returns the first (shortest)
+// pattern covering it, or the exact pattern of the domain itself
+func (t *labelTrie) match(domain string) (scopeMatch, bool) {
+	node := t
+	for end := len(domain); end >= 0; {
+		start := strings.LastIndexByte(domain[:end], '.') + 1
+		child, ok := node.children[domain[start:end]]
+		if !ok {
+			return scopeMatch{}, false
+		}
+		if child.pattern != "" {
+			switch {
+			case child.wildcard:
+				return scopeMatch{child.pattern, MatchWildcard}, true
+			case start == 0:
+				return scopeMatch{child.pattern, MatchExact}, true
+			default:
+				return scopeMatch{child.pattern, MatchImplicitSubdomain}, true
+			}
+		}
+		node = child
+		end = start - 1
+	}
+	if node.exact != "" {
+		return scopeMatch{node.exact, MatchExact}, true
+	}
+	return scopeMatch{}, false
+}
+
+// compileScopeList compiles raw scope patterns. Plain hosts and *.host
+// patterns both match the host and its subdomains, so they share the trie
+// with =host exact patterns. Inner wildcards and re: patterns become regexes,
+// URL rules are kept apart and !patterns are compiled into a separate
+// negation matcher. inScope marks the positive in-scope list, where public
+// suffix patterns are ignored and URL rule hosts count as domain rules.
+func compileScopeList(patterns []string, asnPrefixes map[uint32][]ipRange, inScope bool) *scopeMatcher {
+	m := &scopeMatcher{
+		hosts: newLabelTrie(),
+		asns:  make(map[uint32]string),
+	}
+
+	var negated []string
+	for _, raw := range patterns {
+		pattern := strings.TrimSpace(raw)
+		if pattern == "" {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+			negated = append(negated, strings.TrimSpace(rest))
+			continue
+		}
+
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			regex, err := compileScopeRegex(expr)
+			if err != nil {
+				continue
+			}
+			m.domainRules++
+			m.wildcards = append(m.wildcards, wildcardRule{pattern: raw, kind: MatchRegex, regex: regex})
+			continue
+		}
+
+		if isURLRule(pattern) {
+			rule, err := parseURLRule(raw)
+			if err != nil {
+				continue
+			}
+			if inScope && rule.host != nil && rule.host.domainRules > 0 {
+				m.domainRules++
+				if publicSuffixPattern(rule.hostPattern) {
+					m.publicSuffixes = append(m.publicSuffixes, raw)
+					continue
+				}
+			}
+			m.urlRules = append(m.urlRules, rule)
+			continue
+		}
+
+		pattern = strings.ToLower(pattern)
+
+		if r, ok := parseIPRule(pattern); ok {
+			m.ipRules = append(m.ipRules, ipRule{ipRange: r, pattern: raw, kind: ipRuleType(pattern)})
+			continue
+		}
+
+		if asn, ok := parseASNRule(pattern); ok {
+			m.asns[asn] = raw
+			for _, r := range asnPrefixes[asn] {
+				m.asnRanges = append(m.asnRanges, asnRange{ipRange: r, pattern: raw})
+			}
+			continue
+		}
+
+		m.domainRules++
+		if inScope && publicSuffixPattern(pattern) {
+			m.publicSuffixes = append(m.publicSuffixes, raw)
+			continue
+		}
+		host, exact := strings.CutPrefix(pattern, "=")
+		if !strings.Contains(host, "*") {
+			m.hosts.insert(host, raw, exact, false)
+			continue
+		}
+		if strings.HasPrefix(host, "*.") && !exact {
+			// Like matchesWildcard, the rest of a *. pattern is taken literally
+			m.hosts.insert(host[2:], raw, false, true)
+			continue
+		}
+		pattern = host
+
+		regex, err := regexp.Compile(wildcardToRegex(pattern))
+		if err != nil {
+			continue
+		}
+		m.wildcards = append(m.wildcards, wildcardRule{
+			pattern: raw,
+			kind:    MatchWildcard,
+			suffix:  pattern[strings.LastIndexByte(pattern, '*')+1:],
+			regex:   regex,
+		})
+	}
+
+	sort.Slice(m.ipRules, func(i, j int) bool { return m.ipRules[i].from.Less(m.ipRules[j].from) })
+
+	if len(negated) > 0 {
+		m.negations = compileScopeList(negated, asnPrefixes, false)
+	}
+	return m
+}
+
+func ipRuleType(pattern string) MatchType {
+	switch {
+	case strings.Contains(pattern, "/"):
+		return MatchCIDR
+	case strings.Contains(pattern, "-"):
+		return MatchIPRange
+	default:
+		return MatchExact
+	}
+}
+
+// matchDomain returns the pattern matching an already normalized domain
+func (m *scopeMatcher) matchDomain(domain string) (scopeMatch, bool) {
+	if domain == "" {
+		return scopeMatch{}, false
+	}
+	if match, ok := m.hosts.match(domain); ok {
+		return match, true
+	}
+	for _, w := range m.wildcards {
+		if strings.HasSuffix(domain, w.suffix) && w.regex.MatchString(domain) {
+			return scopeMatch{w.pattern, w.kind}, true
+		}
+	}
+	return scopeMatch{}, false
+}
+
+// matchIP returns the IP, CIDR or range rule containing addr
+func (m *scopeMatcher) matchIP(addr netip.Addr) (scopeMatch, bool) {
+	for _, r := range m.ipRules {
+		if addr.Less(r.from) {
+			// Rules are sorted by start address
+			break
+		}
+		if r.contains(addr) {
+			return scopeMatch{r.pattern, r.kind}, true
+		}
+	}
+	return scopeMatch{}, false
+}
+
+// matchIPToASN returns the ASN rule announcing addr
+func (m *scopeMatcher) matchIPToASN(addr netip.Addr) (scopeMatch, bool) {
+	for _, r := range m.asnRanges {
+		if r.contains(addr) {
+			return scopeMatch{r.pattern, MatchASN}, true
+		}
+	}
+	return scopeMatch{}, false
+}
+
+// matchAddr matches addr against the IP rules, then the ASN rules
+func (m *scopeMatcher) matchAddr(addr netip.Addr) (scopeMatch, bool) {
+	if match, ok := m.matchIP(addr); ok {
+		return match, true
+	}
+	return m.matchIPToASN(addr)
+}
+
+// matchASN returns the AS<number> rule for asn
+func (m *scopeMatcher) matchASN(asn uint32) (scopeMatch, bool) {
+	pattern, ok := m.asns[asn]
+	if !ok {
+		return scopeMatch{}, false
+	}
+	return scopeMatch{pattern, MatchASN}, true
+}
+
+// minParallelItems is the input size below which evaluateScope doesn't
+// bother starting workers
+const minParallelItems = 1024
+
+// evaluateScope runs decide for every item, spread over all CPUs for large
+// inputs. Decisions are returned in input order.
+func evaluateScope(items []string, decide func(string) ScopeDecision) []ScopeDecision {
+	results := make([]ScopeDecision, len(items))
+
+	workers := runtime.NumCPU()
+	if len(items) < minParallelItems || workers < 2 {
+		for i, item := range items {
+			results[i] = decide(item)
+		}
+		return results
+	}
+
+	chunk := (len(items) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(items); start += chunk {
+		end := min(start+chunk, len(items))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				results[i] = decide(items[i])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// TestCompiledMatchesReference checks the compiled matcher against the
+// per-pattern reference matcher, matchesPattern. A !pattern is checked
+// through the negation matcher it compiles into.
+func TestCompiledMatchesReference(t *testing.T) {
+	domains := []string{
+		"example.com",
+		"www.example.com",
+		"a.b.example.com",
+		"notexample.com",
+		"example.com.evil.net",
+		"api.example.com",
+		"api-v2.example.com",
+		"dev.api.example.com",
+		"shop.example.org",
+		"example.org",
+		"internal.corp.example.com",
+		"other.net",
+	}
+	patterns := []struct {
+		kind    string
+		pattern string
+	}{
+		{"exact", "example.com"},
+		{"exact", "Example.COM"},
+		{"exact", "shop.example.org"},
+		{"wildcard subdomain", "*.example.com"},
+		{"wildcard subdomain", "*.corp.example.com"},
+		{"implicit subdomain", "api.example.com"},
+		{"inner wildcard", "api*.example.com"},
+		{"inner wildcard", "*.api.*.com"},
+		{"regex", `re:api(-v\d+)?\.example\.com`},
+		{"regex", `re:[a-z]+\.example\.(com|org)`},
+		{"exact only", "=example.com"},
+		{"exact only", "=www.example.com"},
+		{"exact only wildcard", "=*.example.org"},
+		{"negation", "!internal.corp.example.com"},
+		{"negation", "!*.api.example.com"},
+		{"negation", `!re:dev\..*`},
+		{"negation", "!=example.org"},
+	}
+
+	sm := NewScopeManager("test")
+	for _, tt := range patterns {
+		m := compileScopeList([]string{tt.pattern}, nil, false)
+		reference := tt.pattern
+		if rest, ok := strings.CutPrefix(tt.pattern, "!"); ok {
+			if m.negations == nil {
+				t.Fatalf("%s %q: no negation matcher compiled", tt.kind, tt.pattern)
+			}
+			m, reference = m.negations, rest
+		}
+
+		for _, domain := range domains {
+			_, got := m.matchDomain(domain)
+			want := sm.matchesPattern(domain, reference)
+			if got != want {
+				t.Errorf("%s %q on %s: compiled %v, reference %v", tt.kind, tt.pattern, domain, got, want)
+			}
+		}
+	}
+}
+
+func TestShouldAcceptDomain(t *testing.T) {
+	sm := NewScopeManager("test")
+	sm.setRules(
+		[]string{"*.example.com", "=exact.example.org", "!staging.example.com", "co.uk", "10.0.0.0/8"},
+		[]string{"*.corp.example.com"},
+	)
+
+	tests := []struct {
+		domain string
+		want   bool
+		rule   ScopeRule
+	}{
+		{"example.com", true, RuleInScope},
+		{"WWW.Example.com", true, RuleInScope},
+		{"vpn.corp.example.com", false, RuleOutOfScope},
+		{"staging.example.com", false, RuleNegated},
+		{"api.staging.example.com", false, RuleNegated},
+		{"exact.example.org", true, RuleInScope},
+		{"sub.exact.example.org", false, RuleNoMatch},
+		{"bbc.co.uk", false, RuleNoMatch},
+		{"other.net", false, RuleNoMatch},
+	}
+	for _, tt := range tests {
+		decision := sm.ShouldAcceptDomain(tt.domain)
+		if decision.Accepted != tt.want || decision.Precedence != tt.rule {
+			t.Errorf("%s: got accepted=%v rule=%v, want accepted=%v rule=%v",
+				tt.domain, decision.Accepted, decision.Precedence, tt.want, tt.rule)
+		}
+	}
+
+	// Without in-scope domain patterns everything not excluded is accepted
+	sm.setRules([]string{"10.0.0.0/8"}, nil)
+	if decision := sm.ShouldAcceptDomain("anything.net"); !decision.Accepted || decision.Precedence != RuleDefaultAccept {
+		t.Errorf("anything.net: got accepted=%v rule=%v, want default accept", decision.Accepted, decision.Precedence)
+	}
+}
+
+func TestCompiledAgreesOnBenchScope(t *testing.T) {
+	sm, domains := benchScope(300, 1000)
+	for _, domain := range domains {
+		if got := sm.ShouldAcceptDomain(domain).Accepted; got != referenceAcceptsDomain(sm, domain) {
+			t.Errorf("%s: compiled %v, reference %v", domain, got, !got)
+		}
+	}
+}
+
+// referenceAcceptsDomain is ShouldAcceptDomain evaluated with the per-pattern
+// reference matcher, the baseline for the compiled matcher's benchmarks
+func referenceAcceptsDomain(sm *ScopeManager, domain string) bool {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	for _, pattern := range sm.OutScope {
+		if sm.matchesPattern(domain, pattern) {
+			return false
+		}
+	}
+	positive := 0
+	for _, pattern := range sm.InScope {
+		if negated, ok := strings.CutPrefix(strings.TrimSpace(pattern), "!"); ok {
+			if sm.matchesPattern(domain, negated) {
+				return false
+			}
+			continue
+		}
+		positive++
+	}
+	for _, pattern := range sm.InScope {
+		if !publicSuffixPattern(pattern) && sm.matchesPattern(domain, pattern) {
+			return true
+		}
+	}
+	return positive-countIPRules(sm.InScope)-countASNRules(sm.InScope) == 0
+}
+
+// benchScope builds a scope of numPatterns in-scope patterns, mixing plain
+// hosts, *. wildcards, inner wildcards and CIDRs, and numDomains domains of
+// which about a quarter are unrelated to it
+func benchScope(numPatterns, numDomains int) (*ScopeManager, []string) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	sm := NewScopeManager("bench")
+
+	var roots []string
+	for i := 0; i < numPatterns; i++ {
+		root := fmt.Sprintf("prog%d.example%d.com", i, i%7)
+		roots = append(roots, root)
+		switch i % 20 {
+		case 0, 1:
+			sm.InScope = append(sm.InScope, fmt.Sprintf("api*.%s", root))
+		case 2:
+			sm.InScope = append(sm.InScope, fmt.Sprintf("10.%d.%d.0/24", i/256%256, i%256))
+		case 3, 4, 5, 6, 7:
+			sm.InScope = append(sm.InScope, root)
+		default:
+			sm.InScope = append(sm.InScope, "*."+root)
+		}
+		if i%10 == 0 {
+			sm.OutScope = append(sm.OutScope, "*.internal."+root)
+		}
+	}
+	sm.Compile()
+
+	words := []string{"www", "api", "api-v2", "dev", "internal", "mail", "cdn", "staging", "vpn", "admin"}
+	domains := make([]string, numDomains)
+	for i := range domains {
+		root := roots[rng.IntN(len(roots))]
+		if rng.IntN(4) == 0 {
+			root = fmt.Sprintf("unrelated%d.org", rng.IntN(1000))
+		}
+		domains[i] = fmt.Sprintf("%s.%s.%s", words[rng.IntN(len(words))], words[rng.IntN(len(words))], root)
+	}
+	return sm, domains
+}
+
+// BenchmarkReferenceShouldAcceptDomain is the per-pattern baseline for
+// BenchmarkShouldAcceptDomain, on the same scope and domains
+func BenchmarkReferenceShouldAcceptDomain(b *testing.B) {
+	sm, domains := benchScope(300, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		referenceAcceptsDomain(sm, domains[i%len(domains)])
+	}
+}
+
+func BenchmarkShouldAcceptDomain(b *testing.B) {
+	sm, domains := benchScope(300, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sm.ShouldAcceptDomain(domains[i%len(domains)])
+	}
+}
+
+func BenchmarkEvaluateScope(b *testing.B) {
+	sm, domains := benchScope(300, 200000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evaluateScope(domains, sm.ShouldAcceptDomain)
+	}
+}
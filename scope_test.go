@@ -0,0 +1,131 @@
+package main
+
+import "testing"
+
+func TestParseIPRule(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		ok       bool
+		from, to string
+	}{
+		{"10.0.0.1", true, "10.0.0.1", "10.0.0.1"},
+		{" 10.0.0.0/24 ", true, "10.0.0.0", "10.0.0.255"},
+		{"10.0.0.77/24", true, "10.0.0.0", "10.0.0.255"},
+		{"10.0.0.1-10.0.0.50", true, "10.0.0.1", "10.0.0.50"},
+		{"10.0.0.1-50", true, "10.0.0.1", "10.0.0.50"},
+		{"2001:db8::/126", true, "2001:db8::", "2001:db8::3"},
+		{"10.0.0.50-10.0.0.1", false, "", ""},
+		{"10.0.0.1-2001:db8::1", false, "", ""},
+		{"10.0.0.0/33", false, "", ""},
+		{"example.com", false, "", ""},
+		{"sub-domain.example.com", false, "", ""},
+	}
+	for _, tt := range tests {
+		r, ok := parseIPRule(tt.pattern)
+		if ok != tt.ok {
+			t.Errorf("parseIPRule(%q) ok = %v, want %v", tt.pattern, ok, tt.ok)
+			continue
+		}
+		if ok && (r.from.String() != tt.from || r.to.String() != tt.to) {
+			t.Errorf("parseIPRule(%q) = %s-%s, want %s-%s", tt.pattern, r.from, r.to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestShouldAcceptIP(t *testing.T) {
+	sm := NewScopeManager("test")
+	sm.setRules(
+		[]string{"10.0.0.0/16", "192.168.1.10-20", "!10.0.99.0/24", "*.example.com"},
+		[]string{"10.0.5.0/24"},
+	)
+
+	tests := []struct {
+		ip   string
+		want bool
+		rule ScopeRule
+	}{
+		{"10.0.0.1", true, RuleInScope},
+		{"::ffff:10.0.0.1", true, RuleInScope},
+		{"10.0.5.1", false, RuleOutOfScope},
+		{"10.0.99.1", false, RuleNegated},
+		{"192.168.1.15", true, RuleInScope},
+		{"192.168.1.21", false, RuleNoMatch},
+		{"8.8.8.8", false, RuleNoMatch},
+		{"not-an-ip", false, RuleInvalid},
+	}
+	for _, tt := range tests {
+		decision := sm.ShouldAcceptIP(tt.ip)
+		if decision.Accepted != tt.want || decision.Precedence != tt.rule {
+			t.Errorf("%s: got accepted=%v rule=%v, want accepted=%v rule=%v",
+				tt.ip, decision.Accepted, decision.Precedence, tt.want, tt.rule)
+		}
+	}
+
+	// Domain patterns alone don't restrict IPs
+	sm.setRules([]string{"*.example.com"}, nil)
+	if decision := sm.ShouldAcceptIP("8.8.8.8"); !decision.Accepted || decision.Precedence != RuleDefaultAccept {
+		t.Errorf("8.8.8.8 without IP rules: got accepted=%v rule=%v, want default accept", decision.Accepted, decision.Precedence)
+	}
+
+	// IP rules never match hostnames
+	if sm.matchesPattern("10.0.0.1", "10.0.0.0/8") {
+		t.Error("10.0.0.0/8 matched as a domain pattern")
+	}
+}
+
+func TestParseASN(t *testing.T) {
+	tests := []struct {
+		item     string
+		asn      uint32
+		ok, rule bool
+	}{
+		{"AS13335", 13335, true, true},
+		{"as13335", 13335, true, true},
+		{" AS1 ", 1, true, true},
+		{"13335", 13335, true, false},
+		{"AS", 0, false, false},
+		{"ASX", 0, false, false},
+		{"AS4294967296", 0, false, false},
+		{"example.com", 0, false, false},
+	}
+	for _, tt := range tests {
+		asn, ok := parseASN(tt.item)
+		if ok != tt.ok || asn != tt.asn {
+			t.Errorf("parseASN(%q) = %d, %v, want %d, %v", tt.item, asn, ok, tt.asn, tt.ok)
+		}
+		// Rules need the AS prefix, so plain numbers are never ASN scope
+		if _, rule := parseASNRule(tt.item); rule != tt.rule {
+			t.Errorf("parseASNRule(%q) ok = %v, want %v", tt.item, rule, tt.rule)
+		}
+	}
+}
+
+func TestShouldAcceptASN(t *testing.T) {
+	sm := NewScopeManager("test")
+	sm.setRules([]string{"AS13335", "AS15169", "!AS15169", "*.example.com"}, []string{"AS64512"})
+
+	tests := []struct {
+		asn  string
+		want bool
+		rule ScopeRule
+	}{
+		{"AS13335", true, RuleInScope},
+		{"13335", true, RuleInScope},
+		{"AS15169", false, RuleNegated},
+		{"AS64512", false, RuleOutOfScope},
+		{"AS1", false, RuleNoMatch},
+		{"bogus", false, RuleInvalid},
+	}
+	for _, tt := range tests {
+		decision := sm.ShouldAcceptASN(tt.asn)
+		if decision.Accepted != tt.want || decision.Precedence != tt.rule {
+			t.Errorf("%s: got accepted=%v rule=%v, want accepted=%v rule=%v",
+				tt.asn, decision.Accepted, decision.Precedence, tt.want, tt.rule)
+		}
+	}
+
+	sm.setRules([]string{"*.example.com"}, nil)
+	if decision := sm.ShouldAcceptASN("AS1"); !decision.Accepted || decision.Precedence != RuleDefaultAccept {
+		t.Errorf("AS1 without ASN rules: got accepted=%v rule=%v, want default accept", decision.Accepted, decision.Precedence)
+	}
+}
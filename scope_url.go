@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// urlRule is a compiled URL scope rule, scheme://host[:port][/path]
+type urlRule struct {
+	pattern string
+	// scheme, port and path are empty when any value matches
+	scheme string
+	port   string
+	path   string
+	// pathRegex is set for paths containing *
+	pathRegex *regexp.Regexp
+	// hostPattern is the host part in domain or IP pattern syntax, host its
+	// compiled form; both are empty for a * host
+	hostPattern string
+	host        *scopeMatcher
+}
+
+// urlTarget is a URL reduced to the parts URL rules look at
+type urlTarget struct {
+	scheme string
+	host   string
+	port   string
+	path   string
+}
+
+var urlSchemeChars = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
+// isURLRule reports whether a scope pattern is a URL rule rather than a host
+// pattern
+func isURLRule(pattern string) bool {
+	return strings.Contains(pattern, "://")
+}
+
+// parseURLRule parses a URL rule. Scheme, host and port may be *, and the
+// host takes the usual domain pattern syntax, an IP or an a-b IP range (CIDRs
+// would be read as a path). A path matches itself and everything below it; a
+// * inside it matches any characters including /. Query strings and
+// fragments are not supported.
+func parseURLRule(pattern string) (*urlRule, error) {
+	rule := &urlRule{pattern: pattern}
+
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(pattern), "://")
+	if !ok {
+		return nil, fmt.Errorf("missing scheme://")
+	}
+	scheme = strings.ToLower(scheme)
+	if scheme != "*" {
+		if !urlSchemeChars.MatchString(scheme) {
+			return nil, fmt.Errorf("invalid scheme %q", scheme)
+		}
+		rule.scheme = scheme
+	}
+
+	authority, rulePath := rest, ""
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		authority, rulePath = rest[:i], rest[i:]
+	}
+	if strings.ContainsAny(rulePath, "?#") {
+		return nil, fmt.Errorf("query strings and fragments are not supported")
+	}
+
+	host, port := authority, ""
+	if strings.HasPrefix(authority, "[") {
+		end := strings.IndexByte(authority, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed [ in host")
+		}
+		host = authority[1:end]
+		if after := authority[end+1:]; after != "" {
+			if !strings.HasPrefix(after, ":") {
+				return nil, fmt.Errorf("unexpected %q after host", after)
+			}
+			port = after[1:]
+		}
+	} else if i := strings.LastIndexByte(authority, ':'); i >= 0 {
+		host, port = authority[:i], authority[i+1:]
+	}
+
+	if port != "" && port != "*" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+		rule.port = port
+	}
+
+	host = strings.ToLower(host)
+	switch {
+	case host == "":
+		return nil, fmt.Errorf("missing host, use * for any host")
+	case strings.HasPrefix(host, "!") || strings.HasPrefix(host, "re:"):
+		return nil, fmt.Errorf("host must be a domain pattern or IP, put ! in front of the whole rule")
+	case host != "*":
+		rule.hostPattern = host
+		rule.host = compileScopeList([]string{host}, nil, false)
+	}
+
+	// A trailing /* is the same as a plain path prefix: /api/* matches /api
+	if prefix, ok := strings.CutSuffix(rulePath, "/*"); ok && !strings.Contains(prefix, "*") {
+		rulePath = prefix
+	}
+	switch {
+	case rulePath == "" || rulePath == "/":
+	case strings.Contains(rulePath, "*"):
+		parts := strings.Split(rulePath, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		rule.pathRegex = regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+		rule.path = rulePath
+	default:
+		rule.path = strings.TrimSuffix(rulePath, "/")
+	}
+
+	return rule, nil
+}
+
+// parseURLTarget parses a URL to decide. A missing scheme matches rules of
+// any scheme, a missing port means the scheme's default port, and the path is
+// cleaned so /api/../admin can't pass as /api.
+func parseURLTarget(rawURL string) (urlTarget, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	schemeless := !strings.Contains(rawURL, "://")
+	if schemeless {
+		rawURL = "scheme://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return urlTarget{}, false
+	}
+
+	target := urlTarget{
+		scheme: strings.ToLower(parsed.Scheme),
+		host:   strings.ToLower(strings.TrimSuffix(parsed.Hostname(), ".")),
+		port:   parsed.Port(),
+		path:   path.Clean("/" + parsed.Path),
+	}
+	if schemeless {
+		target.scheme = ""
+	}
+	if target.port == "" {
+		switch target.scheme {
+		case "http", "ws":
+			target.port = "80"
+		case "https", "wss":
+			target.port = "443"
+		}
+	}
+	return target, true
+}
+
+// matches reports whether a URL satisfies every part of the rule
+func (r *urlRule) matches(target urlTarget) bool {
+	if r.scheme != "" && target.scheme != "" && r.scheme != target.scheme {
+		return false
+	}
+	if r.port != "" && r.port != target.port {
+		return false
+	}
+	if !r.matchesHost(target.host) {
+		return false
+	}
+	switch {
+	case r.pathRegex != nil:
+		return r.pathRegex.MatchString(target.path)
+	case r.path != "":
+		return target.path == r.path || strings.HasPrefix(target.path, r.path+"/")
+	}
+	return true
+}
+
+// matchesHost reports whether a domain or IP is matched by the host part
+func (r *urlRule) matchesHost(host string) bool {
+	if r.host == nil {
+		return true
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		_, ok := r.host.matchIP(addr.Unmap())
+		return ok
+	}
+	_, ok := r.host.matchDomain(host)
+	return ok
+}
+
+// matchURL returns the first URL rule of the list matching the URL
+func (m *scopeMatcher) matchURL(target urlTarget) (scopeMatch, bool) {
+	for _, r := range m.urlRules {
+		if r.matches(target) {
+			return scopeMatch{r.pattern, MatchURL}, true
+		}
+	}
+	return scopeMatch{}, false
+}
+
+// matchURLHost returns the first URL rule whose host part is a domain pattern
+// matching the domain. Only in-scope rules are checked this way: a host is in
+// scope when some of its URLs are.
+func (m *scopeMatcher) matchURLHost(domain string) (scopeMatch, bool) {
+	for _, r := range m.urlRules {
+		if r.host == nil || r.host.domainRules == 0 {
+			continue
+		}
+		if _, ok := r.host.matchDomain(domain); ok {
+			return scopeMatch{r.pattern, MatchURL}, true
+		}
+	}
+	return scopeMatch{}, false
+}
+
+// ShouldAcceptURL decides a URL by the URL rules and by its host. Out-of-scope
+// and negated matches reject, URL rules before host patterns; then an
+// in-scope URL rule or an in-scope host pattern accepts. A host that is only
+// in scope through URL rules doesn't put its other URLs in scope: with just
+// https://example.com/api/* in scope, https://example.com/admin is rejected.
+func (sm *ScopeManager) ShouldAcceptURL(rawURL string) ScopeDecision {
+	target, ok := parseURLTarget(rawURL)
+	if !ok {
+		return newScopeDecision(rawURL, RuleInvalid, "not a valid URL")
+	}
+	in, out := sm.matchers()
+
+	if match, ok := out.matchURL(target); ok {
+		return newScopeDecision(rawURL, RuleOutOfScope, "URL matches out-of-scope URL rule").matched("out", match)
+	}
+
+	host := sm.Decide(target.host)
+	host.Item = rawURL
+	if host.Precedence == RuleOutOfScope || host.Precedence == RuleNegated || host.Precedence == RuleInvalid {
+		return host
+	}
+
+	if in.negations != nil {
+		if match, ok := in.negations.matchURL(target); ok {
+			match.Pattern = "!" + match.Pattern
+			return newScopeDecision(rawURL, RuleNegated, "URL matches negated in-scope URL rule").matched("in", match)
+		}
+	}
+
+	if match, ok := in.matchURL(target); ok {
+		return newScopeDecision(rawURL, RuleInScope, "URL matches in-scope URL rule").matched("in", match)
+	}
+
+	switch {
+	case host.Precedence == RuleInScope && host.MatchType != MatchURL:
+		return host
+	case host.Precedence == RuleDefaultAccept && len(in.urlRules) == 0:
+		return host
+	}
+	return newScopeDecision(rawURL, RuleNoMatch, "URL does not match any in-scope URL rule or host pattern")
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestParseURLRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{"https://example.com", true},
+		{"*://*.example.com:8443/api/*", true},
+		{"https://[2001:db8::1]:443/", true},
+		{"http://*", true},
+		{"example.com/api", false},
+		{"ht tp://example.com", false},
+		{"https://example.com:99999", false},
+		{"https://example.com/api?x=1", false},
+		{"https://[2001:db8::1/", false},
+		{"https:///api", false},
+		{"https://!example.com", false},
+	}
+	for _, tt := range tests {
+		if _, err := parseURLRule(tt.pattern); (err == nil) != tt.ok {
+			t.Errorf("parseURLRule(%q) error = %v, want ok %v", tt.pattern, err, tt.ok)
+		}
+	}
+}
+
+func TestShouldAcceptURL(t *testing.T) {
+	sm := NewScopeManager("test")
+	sm.setRules(
+		[]string{"https://example.com/api/*", "*.example.org", "!https://shop.example.org/checkout"},
+		[]string{"*://admin.example.org"},
+	)
+
+	tests := []struct {
+		url  string
+		want bool
+		rule ScopeRule
+	}{
+		{"https://example.com/api", true, RuleInScope},
+		{"https://example.com/api/v1/users", true, RuleInScope},
+		{"example.com/api/v1", true, RuleInScope},
+		{"https://example.com/admin", false, RuleNoMatch},
+		{"https://example.com/api/../admin", false, RuleNoMatch},
+		{"http://example.com/api", false, RuleNoMatch},
+		{"https://example.com:8443/api", true, RuleInScope},
+		{"https://shop.example.org/", true, RuleInScope},
+		{"https://shop.example.org/checkout", false, RuleNegated},
+		{"http://admin.example.org/login", false, RuleOutOfScope},
+		{"https://other.com/", false, RuleNoMatch},
+		{"https://", false, RuleInvalid},
+	}
+	for _, tt := range tests {
+		decision := sm.ShouldAcceptURL(tt.url)
+		if decision.Accepted != tt.want || decision.Precedence != tt.rule {
+			t.Errorf("%s: got accepted=%v rule=%v, want accepted=%v rule=%v",
+				tt.url, decision.Accepted, decision.Precedence, tt.want, tt.rule)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// The server stamps every item with first_seen when it is first added and
+// last_seen each time it is added again. Lists and searches ask for those
+// with detail=true when they filter on them with the parameters below or
+// print them.
+const (
+	seenSinceParam = "first_seen_after"
+	seenUntilParam = "first_seen_before"
+	seenStaleParam = "last_seen_before"
+)
+
+// seenNow is the seen time sent with added items, which the server records
+// as their first_seen or last_seen
+func seenNow() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// timeRefFormats are the accepted forms of a point in time, read in local
+// time
+var timeRefFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeRef parses an absolute time or a duration ago
+func parseTimeRef(ref string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(ref, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if ago, err := time.ParseDuration(ref); err == nil {
+		return time.Now().Add(-ago), nil
+	}
+	for _, format := range timeRefFormats {
+		if at, err := time.ParseInLocation(format, ref, time.Local); err == nil {
+			return at, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't read %q as a time (2006-01-02 15:04) or duration (36h, 7d)", ref)
+}
+
+// addSeenFilterFlags adds --since, --until and --stale to a list command
+func addSeenFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("since", "", "Only items first seen after this time or duration ago (24h, 7d, 2006-01-02)")
+	cmd.Flags().String("until", "", "Only items first seen before this time or duration ago")
+	cmd.Flags().String("stale", "", "Only items not seen again since this time or duration ago (30d)")
+}
+
+// seenFilterQuery turns the --since, --until and --stale flags into query
+// parameters, starting with &. Full records are only asked for when a filter
+// is set or the output isn't a table.
+func seenFilterQuery(cmd *cobra.Command) (string, error) {
+	query := url.Values{}
+	for flag, param := range map[string]string{
+		"since": seenSinceParam,
+		"until": seenUntilParam,
+		"stale": seenStaleParam,
+	} {
+		value, _ := cmd.Flags().GetString(flag)
+		if value == "" {
+			continue
+		}
+		at, err := parseTimeRef(value)
+		if err != nil {
+			return "", fmt.Errorf("--%s: %w", flag, err)
+		}
+		query.Set(param, at.UTC().Format(time.RFC3339))
+	}
+	if len(query) == 0 && outputFormat == "table" {
+		return "", nil
+	}
+	query.Set("detail", "true")
+	return "&" + query.Encode(), nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseTimeRef(t *testing.T) {
+	now := time.Now()
+	relative := []struct {
+		ref string
+		ago time.Duration
+	}{
+		{"36h", 36 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{"0d", 0},
+	}
+	for _, tt := range relative {
+		at, err := parseTimeRef(tt.ref)
+		if err != nil {
+			t.Errorf("parseTimeRef(%q): %v", tt.ref, err)
+			continue
+		}
+		// Days are calendar days, which are an hour off across DST changes
+		if diff := now.Add(-tt.ago).Sub(at); diff < -time.Hour || diff > time.Hour {
+			t.Errorf("parseTimeRef(%q) = %v, want about %v", tt.ref, at, now.Add(-tt.ago))
+		}
+	}
+
+	absolute := []struct {
+		ref  string
+		want time.Time
+	}{
+		{"2026-10-18", time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)},
+		{"2026-10-18 15:04", time.Date(2026, 10, 18, 15, 4, 0, 0, time.Local)},
+		{"2026-10-18T15:04:05", time.Date(2026, 10, 18, 15, 4, 5, 0, time.Local)},
+		{"2026-10-18T15:04:05Z", time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)},
+	}
+	for _, tt := range absolute {
+		if at, err := parseTimeRef(tt.ref); err != nil || !at.Equal(tt.want) {
+			t.Errorf("parseTimeRef(%q) = %v, %v, want %v", tt.ref, at, err, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"", "yesterday", "7 d", "d", "18/10/2026"} {
+		if _, err := parseTimeRef(ref); err == nil {
+			t.Errorf("parseTimeRef(%q): no error", ref)
+		}
+	}
+}
+
+func TestSeenFilterQuery(t *testing.T) {
+	defer func(format string) { outputFormat = format }(outputFormat)
+
+	tests := []struct {
+		format string
+		args   []string
+		want   url.Values
+	}{
+		{"table", nil, nil},
+		{"json", nil, url.Values{"detail": {"true"}}},
+		{"table", []string{"--since", "2026-10-01T00:00:00Z"}, url.Values{
+			"detail":       {"true"},
+			seenSinceParam: {"2026-10-01T00:00:00Z"},
+		}},
+		{"csv", []string{"--until", "2026-10-02T00:00:00Z", "--stale", "2026-10-03T00:00:00Z"}, url.Values{
+			"detail":       {"true"},
+			seenUntilParam: {"2026-10-02T00:00:00Z"},
+			seenStaleParam: {"2026-10-03T00:00:00Z"},
+		}},
+	}
+
+	for _, tt := range tests {
+		outputFormat = tt.format
+		cmd := &cobra.Command{}
+		addSeenFilterFlags(cmd)
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatal(err)
+		}
+
+		query, err := seenFilterQuery(cmd)
+		if err != nil {
+			t.Errorf("%s %v: %v", tt.format, tt.args, err)
+			continue
+		}
+		if tt.want == nil {
+			if query != "" {
+				t.Errorf("%s %v: got %q, want no query", tt.format, tt.args, query)
+			}
+			continue
+		}
+		got, err := url.ParseQuery(strings.TrimPrefix(query, "&"))
+		if !strings.HasPrefix(query, "&") || err != nil || got.Encode() != tt.want.Encode() {
+			t.Errorf("%s %v: got %q, want &%s", tt.format, tt.args, query, tt.want.Encode())
+		}
+	}
+
+	cmd := &cobra.Command{}
+	addSeenFilterFlags(cmd)
+	cmd.ParseFlags([]string{"--stale", "soon"})
+	if _, err := seenFilterQuery(cmd); err == nil || !strings.HasPrefix(err.Error(), "--stale: ") {
+		t.Errorf("--stale soon: got error %v, want one naming the flag", err)
+	}
+}
+
+func TestAddsSendSeen(t *testing.T) {
+	posted := make(map[string]map[string]any)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			posted[r.URL.Path] = body
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+	defer func(old Config, filter bool) { config, enableScopeFilter = old, filter }(config, enableScopeFilter)
+	config = Config{API: server.URL, Token: "test"}
+	enableScopeFilter = false
+
+	before := time.Now().Add(-time.Second)
+	postURLRecords("acme", []URLRecord{{URL: "https://www.example.com/"}}, 0)
+	postServices("acme", []ServiceRecord{{Host: "10.0.0.1", Port: 443, Protocol: "tcp"}})
+
+	for _, endpoint := range []string{urlAddEndpoint, serviceAddEndpoint} {
+		seen, _ := posted[endpoint]["seen"].(string)
+		at, err := time.Parse(time.RFC3339, seen)
+		if err != nil || at.Before(before) || at.After(time.Now()) {
+			t.Errorf("%s: seen %q, want the current time", endpoint, seen)
+		}
+	}
+}
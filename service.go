@@ -0,0 +1,563 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Service endpoints. Services are posted as records carrying links to the
+// IP and domain records they belong to.
+const (
+	serviceAddEndpoint    = "/api/services/add"
+	serviceRemoveEndpoint = "/api/services/remove"
+	serviceListEndpoint   = "/api/services"
+	serviceCountEndpoint  = "/api/services/count"
+)
+
+// ServiceRecord is an open port on a host. IP and Domain name the stored
+// records the service is linked to, and are empty when there are none.
+type ServiceRecord struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Service  string `json:"service,omitempty"`
+	IP       string `json:"ip,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Source   string `json:"source,omitempty"`
+
+	// Set by the server on listed records, see seenFilterQuery
+	FirstSeen string `json:"first_seen,omitempty"`
+	LastSeen  string `json:"last_seen,omitempty"`
+
+	// hostnames are names the scanner reported for the host, used as
+	// linking candidates
+	hostnames []string
+}
+
+// String formats a service as host:port/protocol, bracketing IPv6 hosts
+func (s ServiceRecord) String() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port)) + "/" + s.Protocol
+}
+
+// nmapRun is the part of nmap -oX output that services are read from
+type nmapRun struct {
+	Hosts []struct {
+		Addresses []struct {
+			Addr     string `xml:"addr,attr"`
+			AddrType string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Hostnames []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"hostnames>hostname"`
+		Ports []struct {
+			Protocol string `xml:"protocol,attr"`
+			PortID   int    `xml:"portid,attr"`
+			State    struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+			Service struct {
+				Name string `xml:"name,attr"`
+			} `xml:"service"`
+		} `xml:"ports>port"`
+	} `xml:"host"`
+}
+
+// createServiceCommand builds the service resource, with the usual add,
+// remove, list and count actions
+func createServiceCommand() *cobra.Command {
+	serviceCmd := &cobra.Command{
+		Use:   "service",
+		Short: fmt.Sprintf("%s Service operations", getEmojiForResource("service")),
+		Example: `  # Add naabu output
+  naabu -host example.com | bbrf company service add - -c acme
+
+  # Add an nmap scan
+  bbrf company service add @scan.xml -c acme
+
+  # List HTTPS services
+  bbrf company service list --port 443 -c acme`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add [items...]",
+		Short: "➕ Add services",
+		Long: fmt.Sprintf(`➕ Add services. Supports:
+%s Direct: service add 10.0.0.1:443 example.com:8080/tcp
+%s Stdin: naabu -host example.com | bbrf company service add -
+%s File: bbrf company service add @ports.txt or @scan.xml
+
+Lines are host:port, optionally followed by /protocol and a service name, as
+in "10.0.0.1:443/tcp https". Protocol defaults to tcp. nmap XML (-oX) is
+recognised by its content; only open ports are added.
+
+Each service is linked to the stored IP and domain records of its host, and
+services of out-of-scope hosts are dropped.`, info("•"), info("•"), info("•")),
+		Example: `  # Add services directly
+  bbrf company service add 10.0.0.1:443/tcp example.com:8443 -c acme
+
+  # Add an nmap scan
+  nmap -sV -oX scan.xml 10.0.0.0/24 && bbrf company service add @scan.xml -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			source, _ := cmd.Flags().GetString("source")
+
+			fmt.Printf("%s %s for: %s\n", info("➕"), info("Add services"), info(company))
+
+			input := readInput(args)
+			if source == "" && isNmapXML(input) {
+				source = "nmap"
+			}
+
+			services, invalid, err := parseServices(input)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to parse nmap XML: " + err.Error()))
+				os.Exit(1)
+			}
+			for _, line := range invalid {
+				fmt.Printf("%s Skipped invalid service: %s\n", warning("⚠️"), line)
+			}
+			for i := range services {
+				services[i].Source = source
+			}
+			postServices(company, services)
+		},
+	}
+	addCmd.Flags().String("source", "", "Tool or person that found the services (default nmap for nmap XML)")
+
+	removeCmd := &cobra.Command{
+		Use:   "remove [items...]",
+		Short: "🗑️ Remove services",
+		Example: `  # Remove services directly
+  bbrf company service remove 10.0.0.1:443/tcp -c acme
+
+  # Remove services from file
+  bbrf company service remove @ports.txt -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("%s %s for: %s\n", info("🗑️"), info("Remove services"), info(company))
+			handleInputAndPost(serviceRemoveEndpoint, company, "services", args, nil)
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "📋 List services",
+		Example: `  bbrf company service list -c acme
+
+  # Web ports on one network, for piping into httpx
+  bbrf company service list --port 80,443,8080 --ip 10.0.0.0/24 --plain -c acme
+
+  # Services that haven't shown up in a scan for a month
+  bbrf company service list --stale 30d -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ports, _ := cmd.Flags().GetIntSlice("port")
+			ipFilter, _ := cmd.Flags().GetString("ip")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			plain, _ := cmd.Flags().GetBool("plain")
+			checkOutputFormat()
+			seenQuery, err := seenFilterQuery(cmd)
+			if err != nil {
+				fmt.Println(errorC("❌ " + err.Error()))
+				os.Exit(1)
+			}
+
+			var network netip.Prefix
+			if ipFilter != "" {
+				if network, err = parseIPFilter(ipFilter); err != nil {
+					fmt.Println(errorC("❌ Invalid --ip: " + err.Error()))
+					os.Exit(1)
+				}
+			}
+
+			services, err := fetchServices(company, seenQuery)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to list services: " + err.Error()))
+				os.Exit(1)
+			}
+			services = filterServices(services, ports, network)
+
+			switch {
+			case asJSON || outputFormat == "json":
+				out, _ := json.MarshalIndent(services, "", "  ")
+				fmt.Println(string(out))
+			case outputFormat == "csv":
+				out, _ := json.Marshal(services)
+				renderCSV(out)
+			case plain:
+				for _, service := range services {
+					fmt.Println(net.JoinHostPort(service.Host, strconv.Itoa(service.Port)))
+				}
+			default:
+				printServices(services)
+			}
+		},
+	}
+	listCmd.Flags().IntSlice("port", nil, "Only services on these ports (comma separated)")
+	listCmd.Flags().String("ip", "", "Only services on this IP or CIDR, directly or through their linked IP")
+	listCmd.Flags().Bool("json", false, "Print the service records as JSON, same as --output-format json")
+	listCmd.Flags().Bool("plain", false, "Print only host:port, one per line")
+	addSeenFilterFlags(listCmd)
+
+	countCmd := &cobra.Command{
+		Use:     "count",
+		Short:   "🔢 Count services",
+		Example: "  bbrf company service count -c acme",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(info(fmt.Sprintf("📊 Counting services for: %s", company)))
+			call("GET", serviceCountEndpoint+"?company="+company, "")
+		},
+	}
+
+	serviceCmd.AddCommand(addCmd, removeCmd, listCmd, countCmd)
+	return serviceCmd
+}
+
+// parseServices reads services from nmap XML or from host:port lines,
+// returning the words that couldn't be parsed. Duplicates are dropped.
+func parseServices(input string) ([]ServiceRecord, []string, error) {
+	var services []ServiceRecord
+	var invalid []string
+
+	if isNmapXML(input) {
+		var err error
+		if services, err = parseNmapXML([]byte(input)); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		for _, line := range strings.Split(input, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			// Direct arguments arrive on one line, so every host:port starts
+			// a service and the words after it name the service
+			current := -1
+			for _, field := range strings.Fields(line) {
+				if service, ok := parseServiceTarget(field); ok {
+					services = append(services, service)
+					current = len(services) - 1
+				} else if current >= 0 && !strings.Contains(field, ":") {
+					services[current].Service = strings.TrimSpace(services[current].Service + " " + field)
+				} else {
+					invalid = append(invalid, field)
+					current = -1
+				}
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	unique := services[:0]
+	for _, service := range services {
+		if !seen[service.String()] {
+			seen[service.String()] = true
+			unique = append(unique, service)
+		}
+	}
+	return unique, invalid, nil
+}
+
+// isNmapXML reports whether add input is nmap XML rather than lines
+func isNmapXML(input string) bool {
+	return strings.HasPrefix(strings.TrimSpace(input), "<")
+}
+
+// parseServiceTarget parses host:port[/protocol]
+func parseServiceTarget(target string) (ServiceRecord, bool) {
+	protocol := "tcp"
+	if i := strings.LastIndexByte(target, '/'); i >= 0 {
+		target, protocol = target[:i], strings.ToLower(target[i+1:])
+	}
+	switch protocol {
+	case "tcp", "udp", "sctp":
+	default:
+		return ServiceRecord{}, false
+	}
+
+	host, portText, err := net.SplitHostPort(target)
+	if err != nil {
+		return ServiceRecord{}, false
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil || port < 1 || port > 65535 {
+		return ServiceRecord{}, false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return ServiceRecord{}, false
+	}
+	return ServiceRecord{Host: host, Port: port, Protocol: protocol}, true
+}
+
+// parseNmapXML reads the open ports of nmap -oX output
+func parseNmapXML(content []byte) ([]ServiceRecord, error) {
+	var run nmapRun
+	if err := xml.Unmarshal(content, &run); err != nil {
+		return nil, err
+	}
+
+	var services []ServiceRecord
+	for _, host := range run.Hosts {
+		addr := ""
+		for _, address := range host.Addresses {
+			if address.AddrType == "ipv4" || address.AddrType == "ipv6" {
+				addr = address.Addr
+				break
+			}
+		}
+		if addr == "" {
+			continue
+		}
+
+		var hostnames []string
+		for _, hostname := range host.Hostnames {
+			hostnames = append(hostnames, strings.ToLower(strings.TrimSuffix(hostname.Name, ".")))
+		}
+
+		for _, port := range host.Ports {
+			if port.State.State != "open" {
+				continue
+			}
+			services = append(services, ServiceRecord{
+				Host:      addr,
+				Port:      port.PortID,
+				Protocol:  port.Protocol,
+				Service:   port.Service.Name,
+				hostnames: hostnames,
+			})
+		}
+	}
+	return services, nil
+}
+
+// postServices scope filters services by host, links them to the stored IP
+// and domain records and posts them
+func postServices(company string, services []ServiceRecord) {
+	if enableScopeFilter && !allowOutOfScope {
+		services = filterServicesBeforePost(company, services)
+	} else {
+		printScopeFilterBypassed()
+	}
+	if len(services) == 0 {
+		return
+	}
+
+	linked, err := linkServices(company, services)
+	if err != nil {
+		fmt.Printf("%s Could not load IP and domain records, adding services unlinked: %s\n", warning("⚠️"), err.Error())
+	} else {
+		fmt.Printf("%s Linked %d/%d services to existing IP or domain records\n", info("🔗"), linked, len(services))
+	}
+
+	body := map[string]interface{}{"company": company, "services": services, "seen": seenNow()}
+	if _, err := apiRequest("POST", serviceAddEndpoint, body); err != nil {
+		fmt.Println(errorC("❌ Failed to add services: " + err.Error()))
+		os.Exit(1)
+	}
+	fmt.Println(success(fmt.Sprintf("✅ Added %d services", len(services))))
+}
+
+// filterServicesBeforePost drops services whose host is out of scope
+func filterServicesBeforePost(company string, services []ServiceRecord) []ServiceRecord {
+	if verboseScope {
+		fmt.Printf("%s Loading scope rules for filtering...\n", info("🔍"))
+	}
+
+	scopeManager, ok := loadScopeForFiltering(company)
+	if !ok {
+		return services
+	}
+
+	hosts := make([]string, len(services))
+	for i, service := range services {
+		hosts[i] = service.Host
+	}
+	decisions := evaluateScope(hosts, scopeManager.Decide)
+
+	var accepted []ServiceRecord
+	for i, decision := range decisions {
+		if decision.Accepted {
+			accepted = append(accepted, services[i])
+			if verboseScope {
+				fmt.Printf("%s %s - %s\n", success("✅ ACCEPTED:"), domainClr(services[i].String()), decision.Detail())
+			}
+		} else if verboseScope {
+			fmt.Printf("%s %s - %s\n", errorC("❌ REJECTED:"), domainClr(services[i].String()), decision.Detail())
+		}
+	}
+
+	if len(accepted) != len(services) {
+		fmt.Printf("%s %d/%d services will be added\n", info("ℹ️"), len(accepted), len(services))
+	}
+	if len(accepted) == 0 {
+		fmt.Printf("%s No services passed scope filtering! Nothing will be added.\n", warning("⚠️"))
+	}
+	return accepted
+}
+
+// linkServices fills in the IP and Domain of each service from the stored
+// records: an IP host links to itself and to a domain resolving to it, a
+// domain host to itself and to its resolved IP. Domains are stored as
+// domain or domain:ip. It returns how many services got a link.
+func linkServices(company string, services []ServiceRecord) (int, error) {
+	storedIPs, err := fetchResourceList("/api/ip/list", company)
+	if err != nil {
+		return 0, err
+	}
+	storedDomains, err := fetchResourceList("/api/domains", company)
+	if err != nil {
+		return 0, err
+	}
+
+	ips := make(map[string]bool)
+	for _, ip := range storedIPs {
+		ips[ip] = true
+	}
+	domainIP := make(map[string]string)
+	ipDomain := make(map[string]string)
+	for _, entry := range storedDomains {
+		domain, ip, _ := strings.Cut(entry, ":")
+		domainIP[domain] = ip
+		if ip != "" && ipDomain[ip] == "" {
+			ipDomain[ip] = domain
+		}
+	}
+
+	linked := 0
+	for i := range services {
+		service := &services[i]
+		if _, err := netip.ParseAddr(service.Host); err == nil {
+			if ips[service.Host] {
+				service.IP = service.Host
+			}
+			service.Domain = ipDomain[service.Host]
+			for _, hostname := range service.hostnames {
+				if _, ok := domainIP[hostname]; ok {
+					service.Domain = hostname
+					break
+				}
+			}
+		} else if ip, ok := domainIP[service.Host]; ok {
+			service.Domain = service.Host
+			if ips[ip] {
+				service.IP = ip
+			}
+		}
+		if service.IP != "" || service.Domain != "" {
+			linked++
+		}
+	}
+	return linked, nil
+}
+
+// fetchServices lists the services of a company, accepting plain
+// host:port strings from servers that return those
+func fetchServices(company, seenQuery string) ([]ServiceRecord, error) {
+	respData, err := apiRequest("GET", serviceListEndpoint+"?company="+company+seenQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []ServiceRecord
+	if err := json.Unmarshal(respData, &services); err == nil {
+		return services, nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(respData, &lines); err != nil {
+		return nil, fmt.Errorf("unexpected response: %w", err)
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if service, ok := parseServiceTarget(fields[0]); ok {
+			service.Service = strings.Join(fields[1:], " ")
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// parseIPFilter parses an IP or CIDR as a prefix
+func parseIPFilter(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// filterServices keeps the services on one of the ports, when any are
+// given, and on an IP in the network, when it is valid
+func filterServices(services []ServiceRecord, ports []int, network netip.Prefix) []ServiceRecord {
+	var kept []ServiceRecord
+	for _, service := range services {
+		if len(ports) > 0 && !slices.Contains(ports, service.Port) {
+			continue
+		}
+		if network.IsValid() && !serviceInNetwork(service, network) {
+			continue
+		}
+		kept = append(kept, service)
+	}
+	return kept
+}
+
+// serviceInNetwork reports whether the host or the linked IP of a service
+// is in the network
+func serviceInNetwork(service ServiceRecord, network netip.Prefix) bool {
+	for _, candidate := range []string{service.Host, service.IP} {
+		if addr, err := netip.ParseAddr(candidate); err == nil && network.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// printServices renders services grouped by host, ports in order
+func printServices(services []ServiceRecord) {
+	sort.SliceStable(services, func(i, j int) bool {
+		if services[i].Host != services[j].Host {
+			return services[i].Host < services[j].Host
+		}
+		return services[i].Port < services[j].Port
+	})
+
+	fmt.Println(header(" 🔌 Services "))
+	for i, service := range services {
+		line := domainClr(service.String())
+		if service.Service != "" {
+			line += " " + data(service.Service)
+		}
+		var links []string
+		if service.IP != "" && service.IP != service.Host {
+			links = append(links, service.IP)
+		}
+		if service.Domain != "" && service.Domain != service.Host {
+			links = append(links, service.Domain)
+		}
+		if len(links) > 0 {
+			line += " " + info("→ "+strings.Join(links, ", "))
+		}
+		if service.Source != "" {
+			line += " " + info("("+service.Source+")")
+		}
+		fmt.Printf("%s %s\n", warning(fmt.Sprintf("%d.", i+1)), line)
+	}
+	fmt.Println(count(fmt.Sprintf("\n📊 Total: %d services", len(services))))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestParseServices(t *testing.T) {
+	services, invalid, err := parseServices(
+		"# scan results\n" +
+			"Example.com:443 https\n" +
+			"10.0.0.1:53/udp dns\n" +
+			"[2001:db8::1]:22/tcp\n" +
+			"example.com:443\n" +
+			"10.0.0.1:0 10.0.0.1:80/icmp nohost example.com:8080 http alt\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ServiceRecord{
+		{Host: "example.com", Port: 443, Protocol: "tcp", Service: "https"},
+		{Host: "10.0.0.1", Port: 53, Protocol: "udp", Service: "dns"},
+		{Host: "2001:db8::1", Port: 22, Protocol: "tcp"},
+		{Host: "example.com", Port: 8080, Protocol: "tcp", Service: "http alt"},
+	}
+	if !reflect.DeepEqual(services, want) {
+		t.Errorf("services %+v, want %+v", services, want)
+	}
+	if wantInvalid := []string{"10.0.0.1:0", "10.0.0.1:80/icmp", "nohost"}; !reflect.DeepEqual(invalid, wantInvalid) {
+		t.Errorf("invalid %q, want %q", invalid, wantInvalid)
+	}
+	if got := services[2].String(); got != "[2001:db8::1]:22/tcp" {
+		t.Errorf("String() = %q, want [2001:db8::1]:22/tcp", got)
+	}
+}
+
+func TestParseNmapXML(t *testing.T) {
+	const scan = `<?xml version="1.0"?>
+<nmaprun>
+  <host>
+    <address addr="10.0.0.1" addrtype="ipv4"/>
+    <address addr="00:11:22:33:44:55" addrtype="mac"/>
+    <hostnames><hostname name="App.Example.com." type="PTR"/></hostnames>
+    <ports>
+      <port protocol="tcp" portid="443"><state state="open"/><service name="https"/></port>
+      <port protocol="tcp" portid="8080"><state state="filtered"/></port>
+      <port protocol="udp" portid="53"><state state="open"/><service name="domain"/></port>
+    </ports>
+  </host>
+  <host>
+    <address addr="00:11:22:33:44:66" addrtype="mac"/>
+    <ports><port protocol="tcp" portid="22"><state state="open"/></port></ports>
+  </host>
+</nmaprun>`
+
+	if !isNmapXML("\n  " + scan) {
+		t.Fatal("scan is not recognized as nmap XML")
+	}
+	services, _, err := parseServices(scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostnames := []string{"app.example.com"}
+	want := []ServiceRecord{
+		{Host: "10.0.0.1", Port: 443, Protocol: "tcp", Service: "https", hostnames: hostnames},
+		{Host: "10.0.0.1", Port: 53, Protocol: "udp", Service: "domain", hostnames: hostnames},
+	}
+	if !reflect.DeepEqual(services, want) {
+		t.Errorf("services %+v, want %+v", services, want)
+	}
+
+	if _, _, err := parseServices("<nmaprun><host>"); err == nil {
+		t.Error("truncated XML: no error")
+	}
+}
+
+func TestFilterServices(t *testing.T) {
+	services := []ServiceRecord{
+		{Host: "10.0.0.1", Port: 443, Protocol: "tcp"},
+		{Host: "example.com", Port: 443, Protocol: "tcp", IP: "10.0.0.2"},
+		{Host: "example.com", Port: 22, Protocol: "tcp"},
+		{Host: "192.168.0.1", Port: 80, Protocol: "tcp"},
+	}
+	network, err := parseIPFilter("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ports   []int
+		network netip.Prefix
+		want    []int
+	}{
+		{"no filter", nil, netip.Prefix{}, []int{0, 1, 2, 3}},
+		{"ports", []int{443, 80}, netip.Prefix{}, []int{0, 1, 3}},
+		{"network, host or linked IP", nil, network, []int{0, 1}},
+		{"both", []int{22, 443}, network, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		var want []ServiceRecord
+		for _, i := range tt.want {
+			want = append(want, services[i])
+		}
+		if got := filterServices(services, tt.ports, tt.network); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+
+	if _, err := parseIPFilter("10.0.0.0/40"); err == nil {
+		t.Error("parseIPFilter(10.0.0.0/40): no error")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// fromItemKeys are the JSON keys recon tools put an item under, by resource,
+// in order of preference: subfinder and dnsx use host, amass name, naabu ip,
+// asnmap as_number
+var fromItemKeys = map[string][]string{
+	"domain": {"host", "name", "domain", "subdomain", "input"},
+	"ip":     {"ip", "a", "aaaa", "host"},
+	"asn":    {"as_number", "asn"},
+}
+
+// unknownSource stands for items added without a source
+const unknownSource = "(unknown)"
+
+// statsResources are the resources counted by stats
+var statsResources = []struct {
+	name, countEndpoint, listEndpoint string
+}{
+	{"domains", "/api/domains/count", "/api/domains"},
+	{"ips", "/api/ip/count", "/api/ip/list"},
+	{"asns", "/api/asn/count", "/api/asn/list"},
+	{"urls", urlCountEndpoint, urlListEndpoint},
+	{"services", serviceCountEndpoint, serviceListEndpoint},
+}
+
+// toolOutputItems pulls the items of a resource out of tool output given to
+// add --from. JSON lines give the first of fromItemKeys present, other lines
+// their first field. Duplicates are dropped.
+func toolOutputItems(name, output string) string {
+	var items []string
+	seen := make(map[string]bool)
+	addItem := func(item string) {
+		if item = strings.TrimSpace(item); item != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var record map[string]interface{}
+		if json.Unmarshal([]byte(line), &record) != nil {
+			addItem(strings.Fields(line)[0])
+			continue
+		}
+		for _, key := range fromItemKeys[name] {
+			switch value := record[key].(type) {
+			case string:
+				addItem(value)
+			case float64:
+				// asnmap writes AS numbers as numbers
+				addItem("AS" + strconv.FormatFloat(value, 'f', -1, 64))
+			case []interface{}:
+				for _, v := range value {
+					addItem(fmt.Sprint(v))
+				}
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return strings.Join(items, " ")
+}
+
+// createStatsCommand builds "company stats", counting the stored items of
+// each resource, or with --by-source what each source contributed
+func createStatsCommand() *cobra.Command {
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "📈 Show item counts, optionally by source",
+		Long: `Count the stored domains, IPs, ASNs, URLs and services of a company.
+
+With --by-source, show for each source how many items it found and how many of
+those no other source found. A source with few unique items adds little over
+the others.`,
+		Example: `  # Item counts
+  bbrf company stats -c acme
+
+  # Which tools find what the others don't
+  bbrf company stats --by-source -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			bySource, _ := cmd.Flags().GetBool("by-source")
+			checkOutputFormat()
+
+			if !bySource {
+				var rows [][]string
+				for _, resource := range statsResources {
+					total := "-"
+					if respData, err := apiRequest("GET", resource.countEndpoint+"?company="+company, nil); err == nil {
+						total = strings.TrimSpace(string(respData))
+					}
+					rows = append(rows, []string{resource.name, total})
+				}
+				renderRows([]string{"resource", "count"}, rows)
+				return
+			}
+
+			var rows [][]string
+			for _, resource := range statsResources {
+				records, err := fetchSourceRecords(resource.listEndpoint, company)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s Skipping %s: %s\n", warning("⚠️"), resource.name, err.Error())
+					continue
+				}
+				for _, stat := range sourceStats(records) {
+					rows = append(rows, []string{resource.name, stat.source, strconv.Itoa(stat.items), strconv.Itoa(stat.unique)})
+				}
+			}
+			renderRows([]string{"resource", "source", "items", "unique"}, rows)
+		},
+	}
+	statsCmd.Flags().Bool("by-source", false, "Break counts down by the source that found the items")
+
+	return statsCmd
+}
+
+// fetchSourceRecords lists the items of a resource with their sources
+func fetchSourceRecords(endpoint, company string) ([]map[string]interface{}, error) {
+	respData, err := apiRequest("GET", endpoint+"?company="+company+"&detail=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(respData, &records); err != nil {
+		return nil, fmt.Errorf("the server returned no source data")
+	}
+	return records, nil
+}
+
+// sourceStat is what one source contributed to a resource
+type sourceStat struct {
+	source string
+	items  int
+	unique int
+}
+
+// sourceStats counts the items found by each source, and those found by
+// that source alone, most unique first. A record lists its sources under
+// sources, or just the first one under source.
+func sourceStats(records []map[string]interface{}) []sourceStat {
+	stats := make(map[string]*sourceStat)
+	for _, record := range records {
+		var sources []string
+		if list, ok := record["sources"].([]interface{}); ok {
+			for _, source := range list {
+				sources = append(sources, fmt.Sprint(source))
+			}
+		} else if source, ok := record["source"].(string); ok && source != "" {
+			sources = []string{source}
+		}
+		if len(sources) == 0 {
+			sources = []string{unknownSource}
+		}
+
+		for _, source := range sources {
+			if stats[source] == nil {
+				stats[source] = &sourceStat{source: source}
+			}
+			stats[source].items++
+			if len(sources) == 1 {
+				stats[source].unique++
+			}
+		}
+	}
+
+	result := make([]sourceStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].unique != result[j].unique {
+			return result[i].unique > result[j].unique
+		}
+		if result[i].items != result[j].items {
+			return result[i].items > result[j].items
+		}
+		return result[i].source < result[j].source
+	})
+	return result
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToolOutputItems(t *testing.T) {
+	tests := []struct {
+		name, resource, output, want string
+	}{
+		{
+			name:     "plain lines",
+			resource: "domain",
+			output:   "# found\na.example.com\n\nb.example.com [A] [10.0.0.1]\na.example.com\n",
+			want:     "a.example.com b.example.com",
+		},
+		{
+			name:     "subfinder",
+			resource: "domain",
+			output:   `{"host":"a.example.com","input":"example.com","source":"crtsh"}` + "\n" + `{"host":"b.example.com","input":"example.com"}`,
+			want:     "a.example.com b.example.com",
+		},
+		{
+			name:     "amass",
+			resource: "domain",
+			output:   `{"name":"c.example.com","domain":"example.com"}`,
+			want:     "c.example.com",
+		},
+		{
+			name:     "dnsx",
+			resource: "ip",
+			output:   `{"host":"a.example.com","a":["10.0.0.1","10.0.0.2"]}` + "\n" + `{"host":"b.example.com","a":["10.0.0.2"]}`,
+			want:     "10.0.0.1 10.0.0.2",
+		},
+		{
+			name:     "naabu",
+			resource: "ip",
+			output:   `{"host":"a.example.com","ip":"10.0.0.3","port":443}`,
+			want:     "10.0.0.3",
+		},
+		{
+			name:     "asnmap",
+			resource: "asn",
+			output:   `{"as_number":13335,"as_name":"CLOUDFLARENET"}` + "\n" + `{"as_number":"AS15169"}`,
+			want:     "AS13335 AS15169",
+		},
+		{
+			name:     "no known key",
+			resource: "asn",
+			output:   `{"host":"a.example.com"}`,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := toolOutputItems(tt.resource, tt.output); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSourceStats(t *testing.T) {
+	records := []map[string]interface{}{
+		{"domain": "a.example.com", "sources": []interface{}{"subfinder", "amass"}},
+		{"domain": "b.example.com", "sources": []interface{}{"subfinder"}},
+		{"domain": "c.example.com", "source": "amass"},
+		{"domain": "d.example.com", "source": "amass"},
+		{"domain": "e.example.com"},
+	}
+
+	want := []sourceStat{
+		{source: "amass", items: 3, unique: 2},
+		{source: "subfinder", items: 2, unique: 1},
+		{source: unknownSource, items: 1, unique: 1},
+	}
+	if got := sourceStats(records); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var tagNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// createTagCommand builds "<resource> tag", which adds tags to and removes
+// them from stored items. Items are read like the add command reads them.
+func createTagCommand(name, dataKey, tagEndpoint, untagEndpoint string) *cobra.Command {
+	tagCmd := &cobra.Command{
+		Use:   "tag",
+		Short: fmt.Sprintf("🏷️ Tag %s", name+"s"),
+		Example: fmt.Sprintf(`  # Tag %s
+  bbrf company %s tag add prod item1 item2 -c acme
+
+  # Tag %s from file or stdin
+  bbrf company %s tag add interesting @items.txt -c acme
+  cat items.txt | bbrf company %s tag add waf - -c acme
+
+  # Remove a tag
+  bbrf company %s tag remove staging item1 -c acme
+
+  # List the tagged %s
+  bbrf company %s list --tag prod -c acme`,
+			name+"s", name, name+"s", name, name, name, name+"s", name),
+	}
+
+	for _, action := range []struct {
+		use, emoji, verb, endpoint string
+	}{
+		{"add", "🏷️", "Tag", tagEndpoint},
+		{"remove", "🗑️", "Untag", untagEndpoint},
+	} {
+		action := action // capture loop var
+		tagCmd.AddCommand(&cobra.Command{
+			Use:   action.use + " <tag> [items...]",
+			Short: fmt.Sprintf("%s %s %s", action.emoji, action.verb, name+"s"),
+			Long: fmt.Sprintf(`%s %s %s. Items are given like for add:
+%s Direct: %s tag %s prod item1 item2
+%s Stdin: echo 'item' | bbrf company %s tag %s prod -
+%s File: bbrf company %s tag %s prod @file.txt
+
+Tags are lowercase letters, digits, '-', '_' and '.'.`,
+				action.emoji, action.verb, name+"s",
+				info("•"), name, action.use,
+				info("•"), name, action.use,
+				info("•"), name, action.use),
+			Args: cobra.MinimumNArgs(2),
+			Run: func(cmd *cobra.Command, args []string) {
+				tag := strings.ToLower(args[0])
+				if !tagNameRegex.MatchString(tag) {
+					fmt.Println(errorC("❌ Invalid tag: " + args[0]))
+					os.Exit(1)
+				}
+
+				fmt.Printf("%s %s %s as %s for: %s\n", info(action.emoji), info(action.verb), info(name+"s"), warning(tag), info(company))
+				postInput(action.endpoint, company, dataKey, readInput(args[1:]), map[string]string{"tag": tag})
+			},
+		})
+	}
+
+	return tagCmd
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// recordedRequest is a request seen by the recording test server
+type recordedRequest struct {
+	method, path, query string
+	body                map[string]string
+}
+
+// withRecordingServer points the config at a server that answers every
+// request with an empty list and records it
+func withRecordingServer(t *testing.T) *[]recordedRequest {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		request := recordedRequest{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery}
+		json.Unmarshal(content, &request.body)
+		requests = append(requests, request)
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(server.Close)
+
+	oldConfig, oldCompany := config, company
+	t.Cleanup(func() { config, company = oldConfig, oldCompany })
+	config = Config{API: server.URL, Token: "test"}
+	company = "acme"
+	return &requests
+}
+
+func TestTagRequests(t *testing.T) {
+	requests := withRecordingServer(t)
+	tagCmd := createTagCommand("domain", "domains", "/api/domains/tag", "/api/domains/untag")
+
+	for _, args := range [][]string{
+		{"add", "Prod", "a.example.com", "b.example.com"},
+		{"remove", "staging", "a.example.com"},
+	} {
+		tagCmd.SetArgs(args)
+		if err := tagCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []recordedRequest{
+		{method: "POST", path: "/api/domains/tag", body: map[string]string{
+			"company": "acme", "domains": "a.example.com b.example.com", "tag": "prod",
+		}},
+		{method: "POST", path: "/api/domains/untag", body: map[string]string{
+			"company": "acme", "domains": "a.example.com", "tag": "staging",
+		}},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("requests %+v, want %+v", *requests, want)
+	}
+}
+
+func TestListByTag(t *testing.T) {
+	requests := withRecordingServer(t)
+	domainCmd := createCRUDCommand("domain", "domains", map[string]string{"list": "/api/domains"})
+
+	domainCmd.SetArgs([]string{"list", "--tag", "prod,waf"})
+	if err := domainCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("requests %+v, want one list", *requests)
+	}
+	query, err := url.ParseQuery((*requests)[0].query)
+	if err != nil || query.Get("company") != "acme" || query.Get("tag") != "prod,waf" {
+		t.Errorf("list query %q, want company=acme and tag=prod,waf", (*requests)[0].query)
+	}
+}
+
+func TestTagNames(t *testing.T) {
+	for tag, valid := range map[string]bool{
+		"prod":         true,
+		"waf-2":        true,
+		"v1.2_beta":    true,
+		"-prod":        false,
+		"two words":    false,
+		"prod,staging": false,
+		"":             false,
+	} {
+		if tagNameRegex.MatchString(tag) != valid {
+			t.Errorf("tag %q valid = %v, want %v", tag, !valid, valid)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// URL endpoints. Unlike domains, URLs are posted as records so their HTTP
+// metadata travels with them.
+const (
+	urlAddEndpoint    = "/api/urls/add"
+	urlRemoveEndpoint = "/api/urls/remove"
+	urlListEndpoint   = "/api/urls"
+	urlCountEndpoint  = "/api/urls/count"
+)
+
+// URLRecord is a URL with what was learned about it when it was probed
+type URLRecord struct {
+	URL           string   `json:"url"`
+	StatusCode    int      `json:"status_code,omitempty"`
+	Title         string   `json:"title,omitempty"`
+	ContentLength int64    `json:"content_length,omitempty"`
+	Technologies  []string `json:"technologies,omitempty"`
+	Source        string   `json:"source,omitempty"`
+
+	// Set by the server on listed records, see seenFilterQuery
+	FirstSeen string `json:"first_seen,omitempty"`
+	LastSeen  string `json:"last_seen,omitempty"`
+}
+
+// httpxResult holds the fields of an httpx -json line that URLRecord keeps.
+// httpx has used both spellings of some keys across versions.
+type httpxResult struct {
+	URL              string   `json:"url"`
+	StatusCode       int      `json:"status_code"`
+	StatusCodeDash   int      `json:"status-code"`
+	Title            string   `json:"title"`
+	ContentLength    int64    `json:"content_length"`
+	ContentLengthDsh int64    `json:"content-length"`
+	Tech             []string `json:"tech"`
+	Technologies     []string `json:"technologies"`
+}
+
+// createURLCommand builds the url resource. It has the same add, remove, list
+// and count actions as the resources made by createCRUDCommand, plus import.
+func createURLCommand() *cobra.Command {
+	urlCmd := &cobra.Command{
+		Use:   "url",
+		Short: fmt.Sprintf("%s Url operations", getEmojiForResource("url")),
+		Example: `  # Add URLs with their metadata
+  bbrf company url add https://app.example.com/login --status 200 --title Login -c acme
+
+  # Import httpx output
+  httpx -l subs.txt -json | bbrf company url import - -c acme
+
+  # List URLs with their metadata
+  bbrf company url list -c acme`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add [items...]",
+		Short: "➕ Add urls",
+		Long: fmt.Sprintf(`➕ Add urls. Supports:
+%s Direct: url add https://a.example.com https://b.example.com
+%s Stdin: echo 'https://a.example.com' | bbrf company url add -
+%s File: bbrf company url add @urls.txt
+
+The metadata flags apply to every URL added. URLs are scope filtered by their
+host and by URL scope rules, like domains are.`, info("•"), info("•"), info("•")),
+		Example: `  # Add a URL with its status code and title
+  bbrf company url add https://app.example.com/login --status 200 --title Login -c acme
+
+  # Add crawler output, recording where it came from
+  katana -u https://example.com | bbrf company url add - --source katana -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			status, _ := cmd.Flags().GetInt("status")
+			title, _ := cmd.Flags().GetString("title")
+			contentLength, _ := cmd.Flags().GetInt64("content-length")
+			tech, _ := cmd.Flags().GetStringSlice("tech")
+			source, _ := cmd.Flags().GetString("source")
+
+			fmt.Printf("%s %s for: %s\n", info("➕"), info("Add urls"), info(company))
+
+			var records []URLRecord
+			for _, u := range strings.Fields(readInput(args)) {
+				records = append(records, URLRecord{
+					URL:           u,
+					StatusCode:    status,
+					Title:         title,
+					ContentLength: contentLength,
+					Technologies:  tech,
+					Source:        source,
+				})
+			}
+			postURLRecords(company, records, 0)
+		},
+	}
+	addCmd.Flags().Int("status", 0, "HTTP status code")
+	addCmd.Flags().String("title", "", "Page title")
+	addCmd.Flags().Int64("content-length", 0, "Response content length")
+	addCmd.Flags().StringSlice("tech", nil, "Detected technologies (comma separated)")
+	addCmd.Flags().String("source", "", "Tool or person that found the URLs")
+
+	importCmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "📥 Import URLs from tool output",
+		Long: `Import URLs with their metadata from tool output. The httpx format is the
+JSON lines written by httpx -json; url, status code, title, content length and
+technologies are kept. Lines that aren't valid JSON are skipped.`,
+		Example: `  # Import an httpx result file
+  bbrf company url import httpx.jsonl -c acme
+
+  # Pipe httpx straight in
+  httpx -l subs.txt -json -title -tech-detect | bbrf company url import - -c acme`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, _ := cmd.Flags().GetString("format")
+			source, _ := cmd.Flags().GetString("source")
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+			if format != "httpx" {
+				fmt.Println(errorC("❌ Unsupported format: " + format + " (supported: httpx)"))
+				os.Exit(1)
+			}
+			if source == "" {
+				source = format
+			}
+
+			var input io.Reader = os.Stdin
+			if args[0] != "-" {
+				file, err := os.Open(strings.TrimPrefix(args[0], "@"))
+				if err != nil {
+					fmt.Println(errorC("❌ Failed to open input: " + err.Error()))
+					os.Exit(1)
+				}
+				defer file.Close()
+				input = file
+			}
+
+			records, skipped, err := parseHTTPXResults(input, source)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to read input: " + err.Error()))
+				os.Exit(1)
+			}
+			if skipped > 0 {
+				fmt.Printf("%s Skipped %d lines that were not httpx JSON\n", warning("⚠️"), skipped)
+			}
+			fmt.Printf("%s Importing %d urls for: %s\n", info("📥"), len(records), info(company))
+			postURLRecords(company, records, batchSize)
+		},
+	}
+	importCmd.Flags().String("format", "httpx", "Input format (httpx)")
+	importCmd.Flags().String("source", "", "Source recorded on the URLs (default: the format)")
+	importCmd.Flags().Int("batch-size", 500, "URLs per request")
+
+	removeCmd := &cobra.Command{
+		Use:   "remove [items...]",
+		Short: "🗑️ Remove urls",
+		Example: `  # Remove URLs directly
+  bbrf company url remove https://a.example.com -c acme
+
+  # Remove URLs from file
+  bbrf company url remove @urls.txt -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("%s %s for: %s\n", info("🗑️"), info("Remove urls"), info(company))
+			handleInputAndPost(urlRemoveEndpoint, company, "urls", args, nil)
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "📋 List urls",
+		Example: `  bbrf company url list -c acme
+
+  # Just the URLs, for piping into other tools
+  bbrf company url list --plain -c acme
+
+  # URLs first seen this week, as CSV
+  bbrf company url list --since 7d --output-format csv -c acme`,
+		Run: func(cmd *cobra.Command, args []string) {
+			asJSON, _ := cmd.Flags().GetBool("json")
+			plain, _ := cmd.Flags().GetBool("plain")
+			checkOutputFormat()
+			seenQuery, err := seenFilterQuery(cmd)
+			if err != nil {
+				fmt.Println(errorC("❌ " + err.Error()))
+				os.Exit(1)
+			}
+
+			records, err := fetchURLRecords(company, seenQuery)
+			if err != nil {
+				fmt.Println(errorC("❌ Failed to list urls: " + err.Error()))
+				os.Exit(1)
+			}
+
+			switch {
+			case asJSON || outputFormat == "json":
+				out, _ := json.MarshalIndent(records, "", "  ")
+				fmt.Println(string(out))
+			case outputFormat == "csv":
+				out, _ := json.Marshal(records)
+				renderCSV(out)
+			case plain:
+				for _, record := range records {
+					fmt.Println(record.URL)
+				}
+			default:
+				printURLRecords(records)
+			}
+		},
+	}
+	listCmd.Flags().Bool("json", false, "Print the URL records as JSON, same as --output-format json")
+	listCmd.Flags().Bool("plain", false, "Print only the URLs, one per line")
+	addSeenFilterFlags(listCmd)
+
+	countCmd := &cobra.Command{
+		Use:     "count",
+		Short:   "🔢 Count urls",
+		Example: "  bbrf company url count -c acme",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(info(fmt.Sprintf("📊 Counting urls for: %s", company)))
+			call("GET", urlCountEndpoint+"?company="+company, "")
+		},
+	}
+
+	urlCmd.AddCommand(addCmd, importCmd, removeCmd, listCmd, countCmd)
+	return urlCmd
+}
+
+// parseHTTPXResults reads httpx JSON lines into URL records, returning how
+// many non-blank lines couldn't be used
+func parseHTTPXResults(input io.Reader, source string) ([]URLRecord, int, error) {
+	var records []URLRecord
+	skipped := 0
+
+	scanner := bufio.NewScanner(input)
+	// httpx lines carry response headers and can get long
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var result httpxResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil || result.URL == "" {
+			skipped++
+			continue
+		}
+
+		record := URLRecord{
+			URL:           result.URL,
+			StatusCode:    max(result.StatusCode, result.StatusCodeDash),
+			Title:         strings.TrimSpace(result.Title),
+			ContentLength: max(result.ContentLength, result.ContentLengthDsh),
+			Technologies:  result.Technologies,
+			Source:        source,
+		}
+		if len(result.Tech) > 0 {
+			record.Technologies = result.Tech
+		}
+		records = append(records, record)
+	}
+	return records, skipped, scanner.Err()
+}
+
+// postURLRecords scope filters URL records and posts them to the add
+// endpoint, batchSize at a time when batchSize is positive
+func postURLRecords(company string, records []URLRecord, batchSize int) {
+	if enableScopeFilter && !allowOutOfScope {
+		records = filterURLsBeforePost(company, records)
+	} else {
+		printScopeFilterBypassed()
+	}
+	if len(records) == 0 {
+		return
+	}
+	if batchSize <= 0 {
+		batchSize = len(records)
+	}
+
+	seen := seenNow()
+	added := 0
+	for start := 0; start < len(records); start += batchSize {
+		batch := records[start:min(start+batchSize, len(records))]
+		body := map[string]interface{}{"company": company, "urls": batch, "seen": seen}
+		if _, err := apiRequest("POST", urlAddEndpoint, body); err != nil {
+			fmt.Println(errorC("❌ Failed to add urls: " + err.Error()))
+			os.Exit(1)
+		}
+		added += len(batch)
+		if len(records) > batchSize {
+			fmt.Printf("%s Added %d/%d\n", info("➕"), added, len(records))
+		}
+	}
+	fmt.Println(success(fmt.Sprintf("✅ Added %d urls", added)))
+}
+
+// filterURLsBeforePost drops URL records that are out of scope, judging each
+// by its host and by the URL scope rules
+func filterURLsBeforePost(company string, records []URLRecord) []URLRecord {
+	if verboseScope {
+		fmt.Printf("%s Loading scope rules for filtering...\n", info("🔍"))
+	}
+
+	scopeManager, ok := loadScopeForFiltering(company)
+	if !ok {
+		return records
+	}
+
+	urls := make([]string, len(records))
+	for i, record := range records {
+		urls[i] = record.URL
+	}
+	decisions := evaluateScope(urls, scopeManager.ShouldAcceptURL)
+
+	var accepted []URLRecord
+	for i, decision := range decisions {
+		if decision.Accepted {
+			accepted = append(accepted, records[i])
+			if verboseScope {
+				fmt.Printf("%s %s - %s\n", success("✅ ACCEPTED:"), domainClr(urls[i]), decision.Detail())
+			}
+		} else if verboseScope {
+			fmt.Printf("%s %s - %s\n", errorC("❌ REJECTED:"), domainClr(urls[i]), decision.Detail())
+		}
+	}
+
+	if len(accepted) != len(records) {
+		fmt.Printf("%s %d/%d urls will be added\n", info("ℹ️"), len(accepted), len(records))
+	}
+	if len(accepted) == 0 {
+		fmt.Printf("%s No urls passed scope filtering! Nothing will be added.\n", warning("⚠️"))
+	}
+	return accepted
+}
+
+// fetchURLRecords lists the URLs of a company. A server that only returns
+// plain URLs gives records without metadata.
+func fetchURLRecords(company, seenQuery string) ([]URLRecord, error) {
+	respData, err := apiRequest("GET", urlListEndpoint+"?company="+company+seenQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []URLRecord
+	if err := json.Unmarshal(respData, &records); err == nil {
+		return records, nil
+	}
+
+	var urls []string
+	if err := json.Unmarshal(respData, &urls); err != nil {
+		return nil, fmt.Errorf("unexpected response: %w", err)
+	}
+	for _, u := range urls {
+		records = append(records, URLRecord{URL: u})
+	}
+	return records, nil
+}
+
+// printURLRecords renders URL records the way call renders lists
+func printURLRecords(records []URLRecord) {
+	fmt.Println(header(" 🔗 URLs "))
+	for i, record := range records {
+		line := domainClr(record.URL)
+		if record.StatusCode != 0 {
+			line = statusColor(record.StatusCode)(fmt.Sprintf("[%d]", record.StatusCode)) + " " + line
+		}
+		if record.Title != "" {
+			line += " " + data(fmt.Sprintf("%q", record.Title))
+		}
+		if record.ContentLength != 0 {
+			line += " " + info(fmt.Sprintf("%dB", record.ContentLength))
+		}
+		if len(record.Technologies) > 0 {
+			line += " " + title(strings.Join(record.Technologies, ","))
+		}
+		if record.Source != "" {
+			line += " " + info("("+record.Source+")")
+		}
+		fmt.Printf("%s %s\n", warning(fmt.Sprintf("%d.", i+1)), line)
+	}
+	fmt.Println(count(fmt.Sprintf("\n📊 Total: %d urls", len(records))))
+}
+
+// statusColor picks the colour of an HTTP status code
+func statusColor(status int) func(a ...interface{}) string {
+	switch {
+	case status >= 500:
+		return errorC
+	case status >= 400:
+		return warning
+	case status >= 300:
+		return info
+	}
+	return success
+}